@@ -0,0 +1,252 @@
+package intvector
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"hash/crc32"
+	"io"
+	"sort"
+)
+
+//An archive holds many named vectors in one file. The entries are written one after the other
+//and are followed by an index, so an archive can be written as a stream and read with random access:
+//	"IVA1", entry data..., index, index offset (8 bytes), crc32 of the index (4 bytes), "IVAE"
+//Every index record holds the name, the Codec (0 for the plain Serialized layout), the offset and length
+//of the entry data, the number of elements and the sha256 hash of the serialized vector
+
+var (
+	archiveMagic        = []byte("IVA1")
+	archiveTrailerMagic = []byte("IVAE")
+)
+
+//archiveTrailerSize is the size of the index offset, the index checksum and the trailer magic
+const archiveTrailerSize = 8 + 4 + 4
+
+//ArchiveEntry describes a vector in an archive
+type ArchiveEntry struct {
+	Name   string
+	Codec  Codec
+	Size   int
+	Hash   string
+	offset int64
+	length int64
+}
+
+//ArchiveWriter writes an archive to an io.Writer, every entry is written as soon as it is added
+type ArchiveWriter struct {
+	w       *bufio.Writer
+	offset  int64
+	entries []ArchiveEntry
+	names   map[string]bool
+	closed  bool
+}
+
+//NewArchiveWriter starts a new archive on w
+func NewArchiveWriter(w io.Writer) (*ArchiveWriter, error) {
+	a := &ArchiveWriter{w: bufio.NewWriter(w), names: make(map[string]bool)}
+	if _, err := a.w.Write(archiveMagic); err != nil {
+		return nil, err
+	}
+	a.offset = int64(len(archiveMagic))
+	return a, nil
+}
+
+//countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+//Add writes the vector as a new entry, codec 0 stores it in the plain layout of Serialized
+//and any other Codec compresses it like WriteCompressed with the default level
+func (a *ArchiveWriter) Add(name string, v *Intvector, codec Codec) error {
+	if a.closed {
+		return errors.New("Archive is closed")
+	}
+	if a.names[name] {
+		return errors.New("Duplicate entry " + name)
+	}
+
+	cw := &countingWriter{w: a.w}
+	var err error
+	if codec == 0 {
+		_, err = cw.Write(v.Serialized())
+	} else {
+		err = v.WriteCompressed(cw, codec, -1)
+	}
+	if err != nil {
+		return err
+	}
+
+	a.entries = append(a.entries, ArchiveEntry{
+		Name:   name,
+		Codec:  codec,
+		Size:   v.Size(),
+		Hash:   v.Hash(),
+		offset: a.offset,
+		length: cw.n,
+	})
+	a.names[name] = true
+	a.offset += cw.n
+	return nil
+}
+
+//Close writes the index and the trailer, it does not close the underlying writer
+func (a *ArchiveWriter) Close() error {
+	if a.closed {
+		return nil
+	}
+	a.closed = true
+
+	var index []byte
+	for _, e := range a.entries {
+		hash, _ := hex.DecodeString(e.Hash)
+		index = appendWord(index, len(e.Name))
+		index = append(index, e.Name...)
+		index = append(index, byte(e.Codec))
+		index = appendWord(index, int(e.offset))
+		index = appendWord(index, int(e.length))
+		index = appendWord(index, e.Size)
+		index = append(index, hash...)
+	}
+
+	trailer := appendWord(nil, int(a.offset))
+	trailer = binary.BigEndian.AppendUint32(trailer, crc32.ChecksumIEEE(index))
+	trailer = append(trailer, archiveTrailerMagic...)
+
+	if _, err := a.w.Write(append(index, trailer...)); err != nil {
+		return err
+	}
+	return a.w.Flush()
+}
+
+//ArchiveReader reads an archive with random access, the entries are only loaded when they are asked for
+type ArchiveReader struct {
+	r       io.ReaderAt
+	entries map[string]ArchiveEntry
+}
+
+//OpenArchive reads the index of the archive of the given size in r
+func OpenArchive(r io.ReaderAt, size int64) (*ArchiveReader, error) {
+	if size < int64(len(archiveMagic)+archiveTrailerSize) {
+		return nil, errors.New("Invalid archive")
+	}
+	head := make([]byte, len(archiveMagic))
+	trailer := make([]byte, archiveTrailerSize)
+	if _, err := r.ReadAt(head, 0); err != nil {
+		return nil, err
+	}
+	if _, err := r.ReadAt(trailer, size-archiveTrailerSize); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(head, archiveMagic) || !bytes.Equal(trailer[12:], archiveTrailerMagic) {
+		return nil, errors.New("Invalid archive")
+	}
+
+	indexOffset := int64(binary.BigEndian.Uint64(trailer))
+	if indexOffset < int64(len(archiveMagic)) || indexOffset > size-archiveTrailerSize {
+		return nil, errors.New("Invalid index offset")
+	}
+	index := make([]byte, size-archiveTrailerSize-indexOffset)
+	if _, err := r.ReadAt(index, indexOffset); err != nil {
+		return nil, err
+	}
+	if crc32.ChecksumIEEE(index) != binary.BigEndian.Uint32(trailer[8:]) {
+		return nil, errors.New("Index checksum mismatch")
+	}
+
+	a := &ArchiveReader{r: r, entries: make(map[string]ArchiveEntry)}
+	ir := wordReader{b: index}
+	for !ir.done() {
+		var e ArchiveEntry
+		n, err := ir.word()
+		if err != nil {
+			return nil, err
+		}
+		//the checks are written without additions, so that a corrupted name length or offset cannot overflow them
+		if n < 0 || n > len(ir.b)-1-24-32 {
+			return nil, errors.New("Invalid index")
+		}
+		e.Name = string(ir.b[:n])
+		e.Codec = Codec(ir.b[n])
+		ir.b = ir.b[n+1:]
+
+		fields, err := ir.words(3)
+		if err != nil {
+			return nil, err
+		}
+		e.offset, e.length, e.Size = int64(fields[0]), int64(fields[1]), fields[2]
+		e.Hash = hex.EncodeToString(ir.b[:32])
+		ir.b = ir.b[32:]
+
+		if e.offset < int64(len(archiveMagic)) || e.length < 0 || e.length > indexOffset-e.offset {
+			return nil, errors.New("Invalid index")
+		}
+		a.entries[e.Name] = e
+	}
+	return a, nil
+}
+
+//Names returns the names of all the entries in sorted order
+func (a *ArchiveReader) Names() []string {
+	names := make([]string, 0, len(a.entries))
+	for name := range a.entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//Entry returns the description of the named entry
+func (a *ArchiveReader) Entry(name string) (ArchiveEntry, bool) {
+	e, ok := a.entries[name]
+	return e, ok
+}
+
+//Get loads the named entry and checks it against its hash
+func (a *ArchiveReader) Get(name string) (*Intvector, error) {
+	e, ok := a.entries[name]
+	if !ok {
+		return nil, errors.New("No entry " + name)
+	}
+
+	var v Intvector
+	section := io.NewSectionReader(a.r, e.offset, e.length)
+	if e.Codec == 0 {
+		b := make([]byte, e.length)
+		if _, err := io.ReadFull(section, b); err != nil {
+			return nil, err
+		}
+		if len(b) > 0 {
+			if err := v.DeserializeFrom(b, false); err != nil {
+				return nil, err
+			}
+		}
+	} else if err := v.ReadCompressed(section, false); err != nil {
+		return nil, err
+	}
+
+	if v.Size() != e.Size || v.Hash() != e.Hash {
+		return nil, errors.New("Hash mismatch for entry " + name)
+	}
+	return &v, nil
+}
+
+//Verify loads every entry and checks it against its hash
+func (a *ArchiveReader) Verify() error {
+	for _, name := range a.Names() {
+		if _, err := a.Get(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package intvector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"testing"
+)
+
+//buildArchive writes three entries with different encodings and returns the archive and the vectors
+func buildArchive(t *testing.T) ([]byte, map[string]*Intvector) {
+	vectors := map[string]*Intvector{
+		"plain":      {},
+		"compressed": {},
+		"empty":      {},
+	}
+	for i := 0; i < 1000; i++ {
+		vectors["plain"].Push(i - 500)
+		vectors["compressed"].Push(i * i)
+	}
+
+	var buf bytes.Buffer
+	a, err := NewArchiveWriter(&buf)
+	if err != nil {
+		t.Fatalf("Archive Test failed : NewArchiveWriter returned error %s", err)
+	}
+	for _, e := range []struct {
+		name  string
+		codec Codec
+	}{{"plain", 0}, {"compressed", CodecZlib | Shuffle}, {"empty", CodecGzip}} {
+		if err := a.Add(e.name, vectors[e.name], e.codec); err != nil {
+			t.Fatalf("Archive Test failed : Add returned error %s", err)
+		}
+	}
+	if err := a.Add("plain", vectors["plain"], 0); err == nil {
+		t.Error("Archive Test failed : Add should return error for duplicate name")
+	}
+	if err := a.Close(); err != nil {
+		t.Fatalf("Archive Test failed : Close returned error %s", err)
+	}
+	if err := a.Add("late", vectors["plain"], 0); err == nil {
+		t.Error("Archive Test failed : Add should return error after Close")
+	}
+	return buf.Bytes(), vectors
+}
+
+func TestArchive(t *testing.T) {
+	b, vectors := buildArchive(t)
+
+	r, err := OpenArchive(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("Archive Test failed : OpenArchive returned error %s", err)
+	}
+
+	names := r.Names()
+	want := []string{"compressed", "empty", "plain"}
+	if len(names) != len(want) {
+		t.Fatalf("Archive Test failed : want names %v got %v", want, names)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Errorf("Archive Test failed : want names %v got %v", want, names)
+		}
+	}
+
+	for name, v := range vectors {
+		got, err := r.Get(name)
+		if err != nil {
+			t.Errorf("Archive Test failed : Get(%s) returned error %s", name, err)
+			continue
+		}
+		if got.Hash() != v.Hash() {
+			t.Errorf("Archive Test failed : entry %s differs", name)
+		}
+		if e, _ := r.Entry(name); e.Size != v.Size() || e.Hash != v.Hash() {
+			t.Errorf("Archive Test failed : index of entry %s is wrong", name)
+		}
+	}
+
+	if _, err := r.Get("missing"); err == nil {
+		t.Error("Archive Test failed : Get should return error for missing entry")
+	}
+	if err := r.Verify(); err != nil {
+		t.Errorf("Archive Test failed : Verify returned error %s", err)
+	}
+}
+
+func TestArchiveVerify(t *testing.T) {
+	b, _ := buildArchive(t)
+	r, _ := OpenArchive(bytes.NewReader(b), int64(len(b)))
+	e, _ := r.Entry("plain")
+
+	//corrupt an element of the plain entry, the index is still intact so only Verify can notice
+	b[e.offset+20] ^= 0xff
+	r, err := OpenArchive(bytes.NewReader(b), int64(len(b)))
+	if err != nil {
+		t.Fatalf("Archive Verify Test failed : OpenArchive returned error %s", err)
+	}
+	if err := r.Verify(); err == nil {
+		t.Error("Archive Verify Test failed : corrupted entry was not detected")
+	}
+	if _, err := r.Get("compressed"); err != nil {
+		t.Errorf("Archive Verify Test failed : intact entry returned error %s", err)
+	}
+
+	//a corrupted index must be detected when opening
+	b[len(b)-archiveTrailerSize-1] ^= 0xff
+	if _, err := OpenArchive(bytes.NewReader(b), int64(len(b))); err == nil {
+		t.Error("Archive Verify Test failed : corrupted index was not detected")
+	}
+	if _, err := OpenArchive(bytes.NewReader(b[:10]), 10); err == nil {
+		t.Error("Archive Verify Test failed : should return error for truncated archive")
+	}
+}
+
+func TestOpenArchiveCorruptedIndex(t *testing.T) {
+	b, _ := buildArchive(t)
+	indexOffset := int(binary.BigEndian.Uint64(b[len(b)-archiveTrailerSize:]))
+	//the first entry is "plain", its fields follow the name length, the name and the codec byte
+	fields := indexOffset + 8 + len("plain") + 1
+
+	//each case rewrites a field of the index and fixes up the checksum, so only the checks of the fields can notice
+	for _, c := range []struct {
+		name  string
+		pos   int
+		value uint64
+	}{
+		{"huge name length", indexOffset, math.MaxInt64},
+		{"name length past the index", indexOffset, math.MaxInt64 - 40},
+		{"huge entry length", fields + 8, math.MaxInt64},
+		{"entry past the index", fields + 8, uint64(indexOffset)},
+		{"negative entry offset", fields, math.MaxUint64},
+	} {
+		corrupted := append([]byte{}, b...)
+		binary.BigEndian.PutUint64(corrupted[c.pos:], c.value)
+		crc := crc32.ChecksumIEEE(corrupted[indexOffset : len(b)-archiveTrailerSize])
+		binary.BigEndian.PutUint32(corrupted[len(b)-archiveTrailerSize+8:], crc)
+		if _, err := OpenArchive(bytes.NewReader(corrupted), int64(len(corrupted))); err == nil {
+			t.Errorf("OpenArchive Corrupted Index Test failed : %s was not detected", c.name)
+		}
+	}
+}
@@ -0,0 +1,641 @@
+package intvector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+//The Arrow IPC format stores a table as a schema followed by record batches, each an encapsulated message:
+//	0xFFFFFFFF, int32 length of the metadata, metadata, body
+//The metadata is a Message flatbuffer (Message.fbs, Schema.fbs and File.fbs of the Arrow format), padded to
+//a multiple of 8, and the body has the buffers of the columns at the offsets listed in the metadata.
+//A stream ends with 0xFFFFFFFF 0x00000000. A file is "ARROW1", two bytes of padding, a stream, a Footer
+//flatbuffer with the schema and the position of every record batch, the int32 length of the footer
+//and "ARROW1" again. Unlike Serialized everything is little endian.
+//
+//A vector is written as a single non-nullable Int64 column in record batches of at most arrowBatchRows rows.
+//Any signed or unsigned integer column of any table can be read, including its nulls. Dictionary encoded
+//and compressed columns are not supported
+
+//ErrNull is returned when ReadArrow finds a null with the policy CellError
+var ErrNull = errors.New("Null value")
+
+//errArrow is returned for input that is not valid Arrow IPC
+var errArrow = errors.New("Invalid Arrow IPC data")
+
+//ArrowOptions configures ReadArrow and ReadArrowFile
+type ArrowOptions struct {
+	//Nulls is the policy for null values, CellError fails with ErrNull
+	Nulls CellPolicy
+	//Default is the value of nulls with the policy CellDefault
+	Default int
+	//Append appends the values to the vector instead of replacing its contents
+	Append bool
+}
+
+const (
+	//arrowBatchRows is the largest number of rows written in a single record batch
+	arrowBatchRows = 1 << 16
+	//arrowMaxMetadata is the largest metadata of a message that is read
+	arrowMaxMetadata = 16 << 20
+	//arrowMaxDepth is the deepest nesting of fields that is read
+	arrowMaxDepth = 64
+
+	arrowContinuation = 0xFFFFFFFF
+	//arrowV4 and arrowV5 are the values of MetadataVersion, V5 is written, V4 is the oldest that is read
+	arrowV4 = 3
+	arrowV5 = 4
+)
+
+//the values of the MessageHeader union
+const (
+	arrowSchemaMessage      = 1
+	arrowDictionaryMessage  = 2
+	arrowRecordBatchMessage = 3
+)
+
+//the values of the Type union
+const (
+	arrowNull = iota + 1
+	arrowInt
+	arrowFloatingPoint
+	arrowBinary
+	arrowUtf8
+	arrowBool
+	arrowDecimal
+	arrowDate
+	arrowTime
+	arrowTimestamp
+	arrowInterval
+	arrowList
+	arrowStruct
+	arrowUnion
+	arrowFixedSizeBinary
+	arrowFixedSizeList
+	arrowMap
+	arrowDuration
+	arrowLargeBinary
+	arrowLargeUtf8
+	arrowLargeList
+	arrowRunEndEncoded
+)
+
+var (
+	arrowMagic = []byte("ARROW1")
+	arrowEOS   = []byte{0xFF, 0xFF, 0xFF, 0xFF, 0, 0, 0, 0}
+)
+
+//arrowBlock is the position of a record batch in a file, Block in File.fbs
+type arrowBlock struct {
+	offset int64
+	//meta is the length of the metadata including the continuation, the length and the padding
+	meta int32
+	body int64
+}
+
+//WriteArrow writes the vector as an Arrow IPC stream with a single Int64 column of the given name
+func (v *Intvector) WriteArrow(w io.Writer, column string) error {
+	if _, err := writeArrowStream(&countingWriter{w: w}, v.vec, column); err != nil {
+		return err
+	}
+	_, err := w.Write(arrowEOS)
+	return err
+}
+
+//WriteArrowFile writes the vector as an Arrow IPC file with a single Int64 column of the given name
+func (v *Intvector) WriteArrowFile(w io.Writer, column string) error {
+	cw := &countingWriter{w: w}
+	if _, err := cw.Write(append(append([]byte{}, arrowMagic...), 0, 0)); err != nil {
+		return err
+	}
+	blocks, err := writeArrowStream(cw, v.vec, column)
+	if err != nil {
+		return err
+	}
+	if _, err := cw.Write(arrowEOS); err != nil {
+		return err
+	}
+
+	data := make([]byte, 0, 24*len(blocks))
+	for _, b := range blocks {
+		data = binary.LittleEndian.AppendUint64(data, uint64(b.offset))
+		data = binary.LittleEndian.AppendUint32(data, uint32(b.meta))
+		data = binary.LittleEndian.AppendUint32(data, 0)
+		data = binary.LittleEndian.AppendUint64(data, uint64(b.body))
+	}
+	footer := buildFlatBuffer(fbTableOut{
+		fbScalar(2, arrowV5),
+		fbRef(arrowSchema(column)),
+		fbRef(fbStructs{align: 8}),
+		fbRef(fbStructs{n: len(blocks), align: 8, data: data}),
+	})
+	footer = binary.LittleEndian.AppendUint32(footer, uint32(len(footer)))
+	footer = append(footer, arrowMagic...)
+	_, err = cw.Write(footer)
+	return err
+}
+
+//arrowSchema returns the Schema table of a single Int64 column
+func arrowSchema(column string) fbTableOut {
+	field := fbTableOut{
+		fbRef(column),
+		//nullable is false by default
+		{},
+		fbScalar(1, arrowInt),
+		fbRef(fbTableOut{fbScalar(4, 64), fbScalar(1, 1)}),
+		//no dictionary
+		{},
+		//readers expect the children even when there are none
+		fbRef(fbTables{}),
+	}
+	//endianness is little by default
+	return fbTableOut{{}, fbRef(fbTables{field})}
+}
+
+//writeArrowStream writes the schema and the record batches of s, it returns the positions of the batches
+func writeArrowStream(w *countingWriter, s []int, column string) ([]arrowBlock, error) {
+	if _, err := writeArrowMessage(w, arrowSchemaMessage, arrowSchema(column), nil); err != nil {
+		return nil, err
+	}
+
+	var blocks []arrowBlock
+	body := make([]byte, 0, 8*min(len(s), arrowBatchRows))
+	for i := 0; i < len(s); i += arrowBatchRows {
+		rows := s[i:min(i+arrowBatchRows, len(s))]
+		body = body[:0]
+		for _, n := range rows {
+			body = binary.LittleEndian.AppendUint64(body, uint64(int64(n)))
+		}
+
+		//a single node without nulls, an empty validity bitmap and the values
+		nodes := binary.LittleEndian.AppendUint64(nil, uint64(len(rows)))
+		nodes = binary.LittleEndian.AppendUint64(nodes, 0)
+		buffers := make([]byte, 16, 32)
+		buffers = binary.LittleEndian.AppendUint64(buffers, 0)
+		buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(body)))
+		batch := fbTableOut{
+			fbScalar(8, uint64(len(rows))),
+			fbRef(fbStructs{n: 1, align: 8, data: nodes}),
+			fbRef(fbStructs{n: 2, align: 8, data: buffers}),
+		}
+
+		offset := w.n
+		meta, err := writeArrowMessage(w, arrowRecordBatchMessage, batch, body)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, arrowBlock{offset: offset, meta: int32(meta), body: int64(len(body))})
+	}
+	return blocks, nil
+}
+
+//writeArrowMessage writes an encapsulated message and returns the length of its metadata including the prefix,
+//the body must be padded to a multiple of 8
+func writeArrowMessage(w io.Writer, kind uint8, header fbTableOut, body []byte) (int, error) {
+	meta := buildFlatBuffer(fbTableOut{
+		fbScalar(2, arrowV5),
+		fbScalar(1, uint64(kind)),
+		fbRef(header),
+		fbScalar(8, uint64(len(body))),
+	})
+	prefix := binary.LittleEndian.AppendUint32(nil, arrowContinuation)
+	prefix = binary.LittleEndian.AppendUint32(prefix, uint32(len(meta)))
+	if _, err := w.Write(append(prefix, meta...)); err != nil {
+		return 0, err
+	}
+	if len(body) > 0 {
+		if _, err := w.Write(body); err != nil {
+			return 0, err
+		}
+	}
+	return len(prefix) + len(meta), nil
+}
+
+//arrowColumn is the column that is read and where its data is in a record batch
+type arrowColumn struct {
+	node   int
+	buffer int
+	width  int
+	signed bool
+}
+
+//arrowFindColumn finds the column in the schema by name and otherwise by 0-based index, like ReadCSV
+func arrowFindColumn(schema fbTable, column string) (arrowColumn, error) {
+	if schema.fb == nil {
+		return arrowColumn{}, errArrow
+	}
+	if schema.int16(0, 0) != 0 {
+		return arrowColumn{}, errors.New("Big endian Arrow data is not supported")
+	}
+	pos, n := schema.vector(1, 4)
+	idx := -1
+	for i := 0; i < n && idx < 0; i++ {
+		if schema.elem(pos, i).string(0) == column {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		i, err := strconv.Atoi(column)
+		if err != nil || i < 0 || i >= n {
+			if schema.fb.err != nil {
+				return arrowColumn{}, schema.fb.err
+			}
+			return arrowColumn{}, ErrNoColumn
+		}
+		idx = i
+	}
+
+	//the nodes and buffers of the columns before it come first
+	var col arrowColumn
+	for i := 0; i < idx; i++ {
+		nodes, buffers, err := arrowLayout(schema.elem(pos, i), 0)
+		if err != nil {
+			return arrowColumn{}, err
+		}
+		col.node += nodes
+		col.buffer += buffers
+	}
+
+	field := schema.elem(pos, idx)
+	if field.table(4).fb != nil {
+		return arrowColumn{}, errors.New("Dictionary encoded Arrow columns are not supported")
+	}
+	if field.uint8(2, 0) != arrowInt {
+		return arrowColumn{}, fmt.Errorf("Arrow column %q is not an integer column", field.string(0))
+	}
+	typ := field.table(3)
+	col.width = int(typ.int32(0, 0))
+	col.signed = typ.bool(1, false)
+	if schema.fb.err != nil {
+		return arrowColumn{}, schema.fb.err
+	}
+	if col.width != 8 && col.width != 16 && col.width != 32 && col.width != 64 {
+		return arrowColumn{}, errArrow
+	}
+	return col, nil
+}
+
+//arrowLayout returns the number of nodes and buffers that the column of the field has in a record batch
+func arrowLayout(field fbTable, depth int) (int, int, error) {
+	if depth > arrowMaxDepth {
+		return 0, 0, errArrow
+	}
+	//a dictionary encoded column has the indices, its values are in dictionary batches
+	if field.table(4).fb != nil {
+		return 1, 2, nil
+	}
+
+	nodes, buffers := 1, 0
+	switch field.uint8(2, 0) {
+	case arrowNull, arrowRunEndEncoded:
+	case arrowStruct, arrowFixedSizeList:
+		buffers = 1
+	case arrowInt, arrowFloatingPoint, arrowBool, arrowDecimal, arrowDate, arrowTime, arrowTimestamp,
+		arrowInterval, arrowFixedSizeBinary, arrowDuration, arrowList, arrowLargeList, arrowMap:
+		buffers = 2
+	case arrowBinary, arrowUtf8, arrowLargeBinary, arrowLargeUtf8:
+		buffers = 3
+	case arrowUnion:
+		//the type ids, and the offsets of a dense union
+		switch field.table(3).int16(0, 0) {
+		case 0:
+			buffers = 1
+		case 1:
+			buffers = 2
+		default:
+			return 0, 0, errArrow
+		}
+	default:
+		return 0, 0, fmt.Errorf("Arrow column %q has an unsupported type", field.string(0))
+	}
+
+	pos, n := field.vector(5, 4)
+	for i := 0; i < n; i++ {
+		cn, cb, err := arrowLayout(field.elem(pos, i), depth+1)
+		if err != nil {
+			return 0, 0, err
+		}
+		nodes += cn
+		buffers += cb
+	}
+	return nodes, buffers, field.fb.err
+}
+
+//arrowReader collects the values of a column from record batches
+type arrowReader struct {
+	col  arrowColumn
+	opts ArrowOptions
+	vals []int
+	//rows is the number of rows read so far, nulls included
+	rows int64
+}
+
+//batch reads the values of a RecordBatch, buffer returns length bytes of the body at offset
+func (ar *arrowReader) batch(rb fbTable, buffer func(offset int64, length int64) ([]byte, error)) error {
+	if rb.table(3).fb != nil {
+		return errors.New("Compressed Arrow record batches are not supported")
+	}
+	npos, nn := rb.vector(1, 16)
+	bpos, nb := rb.vector(2, 16)
+	if rb.fb.err != nil {
+		return rb.fb.err
+	}
+	if ar.col.node >= nn || ar.col.buffer+1 >= nb {
+		return errArrow
+	}
+	b := rb.fb.b
+	length := int64(binary.LittleEndian.Uint64(b[npos+16*ar.col.node:]))
+	nulls := int64(binary.LittleEndian.Uint64(b[npos+16*ar.col.node+8:]))
+	validity := bpos + 16*ar.col.buffer
+	values := validity + 16
+
+	width := int64(ar.col.width / 8)
+	data, err := buffer(int64(binary.LittleEndian.Uint64(b[values:])), int64(binary.LittleEndian.Uint64(b[values+8:])))
+	if err != nil {
+		return err
+	}
+	if length < 0 || length > int64(len(data))/width {
+		return errArrow
+	}
+	//the bitmap may be left out if there are no nulls
+	var bitmap []byte
+	if nulls != 0 {
+		bitmap, err = buffer(int64(binary.LittleEndian.Uint64(b[validity:])), int64(binary.LittleEndian.Uint64(b[validity+8:])))
+		if err != nil {
+			return err
+		}
+		if int64(len(bitmap)) < (length+7)/8 {
+			if nulls > 0 || len(bitmap) > 0 {
+				return errArrow
+			}
+			bitmap = nil
+		}
+	}
+
+	for i := 0; i < int(length); i++ {
+		row := ar.rows + int64(i)
+		if bitmap != nil && bitmap[i/8]&(1<<(i%8)) == 0 {
+			switch ar.opts.Nulls {
+			case CellSkip:
+			case CellDefault:
+				ar.vals = append(ar.vals, ar.opts.Default)
+			default:
+				return fmt.Errorf("Row %d: %w", row, ErrNull)
+			}
+			continue
+		}
+
+		var x int64
+		var u uint64
+		p := data[i*int(width):]
+		switch ar.col.width {
+		case 8:
+			u, x = uint64(p[0]), int64(int8(p[0]))
+		case 16:
+			u = uint64(binary.LittleEndian.Uint16(p))
+			x = int64(int16(u))
+		case 32:
+			u = uint64(binary.LittleEndian.Uint32(p))
+			x = int64(int32(u))
+		case 64:
+			u = binary.LittleEndian.Uint64(p)
+			x = int64(u)
+		}
+		if !ar.col.signed {
+			if u > math.MaxInt64 {
+				return fmt.Errorf("Row %d: %w", row, ErrOverflow)
+			}
+			x = int64(u)
+		}
+		n := int(x)
+		if int64(n) != x {
+			return fmt.Errorf("Row %d: %w", row, ErrOverflow)
+		}
+		ar.vals = append(ar.vals, n)
+	}
+	ar.rows += length
+	return nil
+}
+
+//done sets the contents of the vector to the values that were read
+func (ar *arrowReader) done(v *Intvector) {
+	if !ar.opts.Append {
+		v.replace(ar.vals)
+	} else {
+		v.Insert(ar.vals...)
+	}
+}
+
+//parseArrowMessage parses the metadata of a message and returns the type and the table of its header
+func parseArrowMessage(meta []byte) (uint8, fbTable, int64, error) {
+	fb := &flatBuffer{b: meta}
+	msg := fb.root()
+	version := msg.int16(0, 0)
+	kind := msg.uint8(1, 0)
+	header := msg.table(2)
+	bodyLength := msg.int64(3, 0)
+	if fb.err != nil {
+		return 0, fbTable{}, 0, fb.err
+	}
+	if version < arrowV4 {
+		return 0, fbTable{}, 0, errors.New("Arrow metadata versions before V4 are not supported")
+	}
+	if header.fb == nil || bodyLength < 0 {
+		return 0, fbTable{}, 0, errArrow
+	}
+	return kind, header, bodyLength, nil
+}
+
+//readArrowMessage reads the next encapsulated message of a stream, its header is the zero fbTable at the
+//end of the stream. The prefix of the streams written before Arrow 0.15 without the continuation is accepted
+func readArrowMessage(r io.Reader) (uint8, fbTable, []byte, error) {
+	var prefix [4]byte
+	if _, err := io.ReadFull(r, prefix[:]); err != nil {
+		//the end of the input is the end of the stream too
+		if err == io.EOF {
+			return 0, fbTable{}, nil, nil
+		}
+		return 0, fbTable{}, nil, err
+	}
+	length := binary.LittleEndian.Uint32(prefix[:])
+	if length == arrowContinuation {
+		if _, err := io.ReadFull(r, prefix[:]); err != nil {
+			return 0, fbTable{}, nil, unexpectedEOF(err)
+		}
+		length = binary.LittleEndian.Uint32(prefix[:])
+	}
+	if length == 0 {
+		return 0, fbTable{}, nil, nil
+	}
+	if length > arrowMaxMetadata {
+		return 0, fbTable{}, nil, errArrow
+	}
+
+	meta := make([]byte, length)
+	if _, err := io.ReadFull(r, meta); err != nil {
+		return 0, fbTable{}, nil, unexpectedEOF(err)
+	}
+	kind, header, bodyLength, err := parseArrowMessage(meta)
+	if err != nil {
+		return 0, fbTable{}, nil, err
+	}
+	//the body grows as it is read, so a corrupt length can not allocate more than the input has
+	var body bytes.Buffer
+	if n, err := io.Copy(&body, io.LimitReader(r, bodyLength)); err != nil || n < bodyLength {
+		return 0, fbTable{}, nil, unexpectedEOF(err)
+	}
+	return kind, header, body.Bytes(), nil
+}
+
+//unexpectedEOF returns io.ErrUnexpectedEOF for a message cut off at its end
+func unexpectedEOF(err error) error {
+	if err == nil || err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+//bodyBuffer returns the function that slices the buffers out of a message body
+func bodyBuffer(body []byte) func(int64, int64) ([]byte, error) {
+	return func(offset int64, length int64) ([]byte, error) {
+		if offset < 0 || length < 0 || offset > int64(len(body)) || length > int64(len(body))-offset {
+			return nil, errArrow
+		}
+		return body[offset : offset+length], nil
+	}
+}
+
+//ReadArrow reads the integer column of an Arrow IPC stream. The column is looked up by name and otherwise
+//taken as a 0-based index like in ReadCSV. The stream is read one record batch at a time and the vector is
+//only changed once all of it has been read, so on an error it is left as it was
+func (v *Intvector) ReadArrow(r io.Reader, column string, opts ArrowOptions) error {
+	kind, schema, _, err := readArrowMessage(r)
+	if err != nil {
+		return err
+	}
+	if schema.fb == nil || kind != arrowSchemaMessage {
+		return errArrow
+	}
+	col, err := arrowFindColumn(schema, column)
+	if err != nil {
+		return err
+	}
+
+	ar := &arrowReader{col: col, opts: opts}
+	for {
+		kind, header, body, err := readArrowMessage(r)
+		if err != nil {
+			return err
+		}
+		if header.fb == nil {
+			break
+		}
+		switch kind {
+		case arrowRecordBatchMessage:
+			if err := ar.batch(header, bodyBuffer(body)); err != nil {
+				return err
+			}
+		case arrowDictionaryMessage:
+			//only used by dictionary encoded columns, which are not read
+		default:
+			return errArrow
+		}
+	}
+	ar.done(v)
+	return nil
+}
+
+//ReadArrowFile reads the integer column of an Arrow IPC file of the given size like ReadArrow. Only the
+//metadata and the buffers of the column are read, the other columns are skipped
+func (v *Intvector) ReadArrowFile(r io.ReaderAt, size int64, column string, opts ArrowOptions) error {
+	trailerSize := int64(4 + len(arrowMagic))
+	if size < 8+trailerSize {
+		return errArrow
+	}
+	head := make([]byte, len(arrowMagic))
+	trailer := make([]byte, trailerSize)
+	if _, err := r.ReadAt(head, 0); err != nil {
+		return err
+	}
+	if _, err := r.ReadAt(trailer, size-trailerSize); err != nil {
+		return err
+	}
+	if !bytes.Equal(head, arrowMagic) || !bytes.Equal(trailer[4:], arrowMagic) {
+		return errArrow
+	}
+	footerSize := int64(binary.LittleEndian.Uint32(trailer))
+	if footerSize > size-8-trailerSize {
+		return errArrow
+	}
+	//everything before the footer holds the messages
+	end := size - trailerSize - footerSize
+	fb := &flatBuffer{b: make([]byte, footerSize)}
+	if _, err := r.ReadAt(fb.b, end); err != nil {
+		return err
+	}
+
+	footer := fb.root()
+	col, err := arrowFindColumn(footer.table(1), column)
+	if err != nil {
+		return err
+	}
+	pos, n := footer.vector(3, 24)
+	if fb.err != nil {
+		return fb.err
+	}
+
+	ar := &arrowReader{col: col, opts: opts}
+	for i := 0; i < n; i++ {
+		block := fb.b[pos+24*i:]
+		offset := int64(binary.LittleEndian.Uint64(block))
+		metaSize := int64(int32(binary.LittleEndian.Uint32(block[8:])))
+		bodySize := int64(binary.LittleEndian.Uint64(block[16:]))
+		if offset < 8 || metaSize < 8 || metaSize > arrowMaxMetadata || bodySize < 0 ||
+			offset > end-metaSize || bodySize > end-metaSize-offset {
+			return errArrow
+		}
+
+		meta := make([]byte, metaSize)
+		if _, err := r.ReadAt(meta, offset); err != nil {
+			return err
+		}
+		length := binary.LittleEndian.Uint32(meta)
+		meta = meta[4:]
+		if length == arrowContinuation {
+			length = binary.LittleEndian.Uint32(meta)
+			meta = meta[4:]
+		}
+		if int64(length) > int64(len(meta)) {
+			return errArrow
+		}
+		kind, header, _, err := parseArrowMessage(meta[:length])
+		if err != nil {
+			return err
+		}
+		if kind != arrowRecordBatchMessage {
+			return errArrow
+		}
+
+		bodyOffset := offset + metaSize
+		err = ar.batch(header, func(off int64, length int64) ([]byte, error) {
+			if off < 0 || length < 0 || off > bodySize || length > bodySize-off {
+				return nil, errArrow
+			}
+			b := make([]byte, length)
+			if _, err := r.ReadAt(b, bodyOffset+off); err != nil {
+				return nil, err
+			}
+			return b, nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	ar.done(v)
+	return nil
+}
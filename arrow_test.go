@@ -0,0 +1,407 @@
+package intvector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+//The files in testdata were written by the Go reference implementation of Arrow, see testdata/arrowgen,
+//which also checked that it reads intvector.arrows and intvector.arrow as written by WriteArrow and WriteArrowFile.
+//The other tests check the layout of the written bytes against the format and read streams assembled here
+
+func TestArrowRoundTrip(t *testing.T) {
+	big := make([]int, 2*arrowBatchRows+5)
+	for i := range big {
+		big[i] = i*7 - 1000
+	}
+	for _, s := range [][]int{nil, {1, 2, 3}, {math.MinInt, -1, 0, math.MaxInt}, big} {
+		v := Intvector{}
+		v.Insert(s...)
+
+		var stream, file bytes.Buffer
+		if err := v.WriteArrow(&stream, "values"); err != nil {
+			t.Fatal(err)
+		}
+		if err := v.WriteArrowFile(&file, "values"); err != nil {
+			t.Fatal(err)
+		}
+
+		u := Intvector{}
+		u.Insert(99)
+		if err := u.ReadArrow(bytes.NewReader(stream.Bytes()), "values", ArrowOptions{}); err != nil || !equalInts(u.vec, s) {
+			t.Errorf("ArrowRoundTrip Test failed : stream of %d want equal got %d, %v", len(s), len(u.vec), err)
+		}
+		u.Insert(99)
+		if err := u.ReadArrowFile(bytes.NewReader(file.Bytes()), int64(file.Len()), "0", ArrowOptions{}); err != nil || !equalInts(u.vec, s) {
+			t.Errorf("ArrowRoundTrip Test failed : file of %d want equal got %d, %v", len(s), len(u.vec), err)
+		}
+	}
+
+	v := Intvector{}
+	v.Insert(1, 2)
+	var b bytes.Buffer
+	v.WriteArrow(&b, "a")
+	v.Insert(0)
+	if err := v.ReadArrow(&b, "a", ArrowOptions{Append: true}); err != nil || !reflect.DeepEqual(v.vec, []int{1, 2, 0, 1, 2}) {
+		t.Errorf("ArrowRoundTrip Test failed : want [1 2 0 1 2] got %v, %v", v.vec, err)
+	}
+}
+
+func TestArrowLayout(t *testing.T) {
+	v := Intvector{}
+	v.Insert(1, -2, 3)
+	var b bytes.Buffer
+	v.WriteArrowFile(&b, "x")
+	file := b.Bytes()
+
+	if !bytes.Equal(file[:8], []byte("ARROW1\x00\x00")) || !bytes.Equal(file[len(file)-6:], arrowMagic) {
+		t.Fatalf("ArrowLayout Test failed : want the magic at both ends")
+	}
+	footerSize := int(binary.LittleEndian.Uint32(file[len(file)-10:]))
+	footerStart := len(file) - 10 - footerSize
+	if footerStart%8 != 0 || !bytes.Equal(file[footerStart-8:footerStart], arrowEOS) {
+		t.Errorf("ArrowLayout Test failed : want an aligned footer after the end of stream")
+	}
+
+	//the schema message
+	if binary.LittleEndian.Uint32(file[8:]) != arrowContinuation {
+		t.Fatalf("ArrowLayout Test failed : want the continuation marker")
+	}
+	metaSize := int(binary.LittleEndian.Uint32(file[12:]))
+	if metaSize%8 != 0 {
+		t.Errorf("ArrowLayout Test failed : want the metadata padded to 8 got %d", metaSize)
+	}
+	kind, schema, bodyLength, err := parseArrowMessage(file[16 : 16+metaSize])
+	if err != nil || kind != arrowSchemaMessage || bodyLength != 0 {
+		t.Fatalf("ArrowLayout Test failed : want a schema got %d, %v", kind, err)
+	}
+	pos, n := schema.vector(1, 4)
+	field := schema.elem(pos, 0)
+	typ := field.table(3)
+	if n != 1 || field.string(0) != "x" || field.bool(1, false) || field.uint8(2, 0) != arrowInt ||
+		typ.int32(0, 0) != 64 || !typ.bool(1, false) {
+		t.Errorf("ArrowLayout Test failed : want a single non-nullable int64 field named x")
+	}
+	if _, n := field.vector(5, 4); field.field(5) == 0 || n != 0 {
+		t.Errorf("ArrowLayout Test failed : want an empty children vector")
+	}
+
+	//the footer points at the record batch, whose body has the values in little endian
+	fb := &flatBuffer{b: file[footerStart : footerStart+footerSize]}
+	footer := fb.root()
+	if footer.int16(0, 0) != arrowV5 {
+		t.Errorf("ArrowLayout Test failed : want version V5")
+	}
+	pos, n = footer.vector(3, 24)
+	if n != 1 {
+		t.Fatalf("ArrowLayout Test failed : want 1 block got %d", n)
+	}
+	offset := int(binary.LittleEndian.Uint64(fb.b[pos:]))
+	meta := int(binary.LittleEndian.Uint32(fb.b[pos+8:]))
+	body := int(binary.LittleEndian.Uint64(fb.b[pos+16:]))
+	if offset != 16+metaSize || offset%8 != 0 || body != 24 || binary.LittleEndian.Uint32(file[offset:]) != arrowContinuation {
+		t.Fatalf("ArrowLayout Test failed : got block %d %d %d", offset, meta, body)
+	}
+	kind, batch, _, err := parseArrowMessage(file[offset+8 : offset+meta])
+	if err != nil || kind != arrowRecordBatchMessage || batch.int64(0, 0) != 3 {
+		t.Errorf("ArrowLayout Test failed : want a record batch of 3 rows got %d, %v", kind, err)
+	}
+	values := file[offset+meta : offset+meta+body]
+	want := []byte{1, 0, 0, 0, 0, 0, 0, 0, 0xFE, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 3, 0, 0, 0, 0, 0, 0, 0}
+	if !bytes.Equal(values, want) {
+		t.Errorf("ArrowLayout Test failed : want body %v got %v", want, values)
+	}
+
+	//the stream is the same messages followed by the end of stream
+	var stream bytes.Buffer
+	v.WriteArrow(&stream, "x")
+	if !bytes.Equal(stream.Bytes(), file[8:footerStart]) {
+		t.Error("ArrowLayout Test failed : want the stream embedded in the file")
+	}
+}
+
+//testBatch is a record batch with the given nodes and buffers, which are laid out in the body in order
+type testBatch struct {
+	length  int64
+	nodes   []int64
+	buffers [][]byte
+}
+
+//testField returns a Field table
+func testField(name string, typ uint8, typeTable fbTableOut, children ...fbTableOut) fbTableOut {
+	return fbTableOut{fbRef(name), fbScalar(1, 1), fbScalar(1, uint64(typ)), fbRef(typeTable), {}, fbRef(fbTables(children))}
+}
+
+//testStream returns an Arrow stream with the fields and batches, legacy leaves out the continuation markers
+func testStream(fields fbTables, batches []testBatch, legacy bool) []byte {
+	var b bytes.Buffer
+	messages := []struct {
+		kind   uint8
+		header fbTableOut
+		body   []byte
+	}{{arrowSchemaMessage, fbTableOut{{}, fbRef(fields)}, nil}}
+
+	for _, tb := range batches {
+		var nodes, buffers, body []byte
+		for _, n := range tb.nodes {
+			nodes = binary.LittleEndian.AppendUint64(nodes, uint64(n))
+		}
+		for _, buf := range tb.buffers {
+			buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(body)))
+			buffers = binary.LittleEndian.AppendUint64(buffers, uint64(len(buf)))
+			body = append(body, buf...)
+			for len(body)%8 != 0 {
+				body = append(body, 0)
+			}
+		}
+		header := fbTableOut{
+			fbScalar(8, uint64(tb.length)),
+			fbRef(fbStructs{n: len(tb.nodes) / 2, align: 8, data: nodes}),
+			fbRef(fbStructs{n: len(tb.buffers), align: 8, data: buffers}),
+		}
+		messages = append(messages, struct {
+			kind   uint8
+			header fbTableOut
+			body   []byte
+		}{arrowRecordBatchMessage, header, body})
+	}
+
+	for _, m := range messages {
+		var msg bytes.Buffer
+		writeArrowMessage(&msg, m.kind, m.header, m.body)
+		if legacy {
+			b.Write(msg.Bytes()[4:])
+		} else {
+			b.Write(msg.Bytes())
+		}
+	}
+	if !legacy {
+		b.Write(arrowEOS)
+	}
+	return b.Bytes()
+}
+
+//le returns the values in little endian with the given width in bytes
+func le(width int, s ...int64) []byte {
+	var b []byte
+	for _, n := range s {
+		b = binary.LittleEndian.AppendUint64(b, uint64(n))[:len(b)+width]
+	}
+	return b
+}
+
+func TestArrowGolden(t *testing.T) {
+	v := Intvector{}
+	v.Insert(math.MinInt32, -1, 0, 1, 42, math.MaxInt32)
+	for _, g := range []struct {
+		name  string
+		write func(w io.Writer, column string) error
+	}{{"intvector.arrows", v.WriteArrow}, {"intvector.arrow", v.WriteArrowFile}} {
+		want, err := os.ReadFile(filepath.Join("testdata", g.name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if err := g.write(&buf, "values"); err != nil || !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("ArrowGolden Test failed : %s differs from the output checked by the reference reader (err %v)", g.name, err)
+		}
+	}
+
+	stream, err := os.ReadFile(filepath.Join("testdata", "reference.arrows"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.ReadFile(filepath.Join("testdata", "reference.arrow"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	read := map[string]func(v *Intvector, column string, opts ArrowOptions) error{
+		"stream": func(v *Intvector, column string, opts ArrowOptions) error {
+			return v.ReadArrow(bytes.NewReader(stream), column, opts)
+		},
+		"file": func(v *Intvector, column string, opts ArrowOptions) error {
+			return v.ReadArrowFile(bytes.NewReader(file), int64(len(file)), column, opts)
+		},
+	}
+
+	//both files have the columns id int64, score int32 with nulls, name utf8 and small uint8 in two batches
+	cases := []struct {
+		column string
+		opts   ArrowOptions
+		want   []int
+	}{
+		{"id", ArrowOptions{}, []int{0, 1, 2, 3, 4, 5, 6, 7}},
+		{"score", ArrowOptions{Nulls: CellSkip}, []int{-7, 13, math.MaxInt32, math.MinInt32, 5}},
+		{"1", ArrowOptions{Nulls: CellDefault, Default: -1}, []int{-7, -1, 13, -1, math.MaxInt32, math.MinInt32, -1, 5}},
+		{"small", ArrowOptions{}, []int{255, 1, 2, 3, 4, 5, 6, 7}},
+	}
+	for kind, f := range read {
+		for _, c := range cases {
+			v := Intvector{}
+			if err := f(&v, c.column, c.opts); err != nil || !reflect.DeepEqual(v.vec, c.want) {
+				t.Errorf("ArrowGolden Test failed : %s column %s want %v got %v, %v", kind, c.column, c.want, v.vec, err)
+			}
+		}
+
+		v := Intvector{}
+		if err := f(&v, "score", ArrowOptions{}); !errors.Is(err, ErrNull) || err.Error() != "Row 1: Null value" {
+			t.Errorf("ArrowGolden Test failed : %s want ErrNull at row 1 got %v", kind, err)
+		}
+		if err := f(&v, "name", ArrowOptions{}); err == nil {
+			t.Errorf("ArrowGolden Test failed : %s want an error for a string column", kind)
+		}
+	}
+}
+
+func TestArrowColumns(t *testing.T) {
+	int32Type := fbTableOut{fbScalar(4, 32), fbScalar(1, 1)}
+	fields := fbTables{
+		testField("name", arrowUtf8, fbTableOut{}),
+		testField("point", arrowStruct, fbTableOut{}, testField("px", arrowInt, int32Type), testField("py", arrowInt, int32Type)),
+		testField("x", arrowInt, int32Type),
+		testField("u", arrowInt, fbTableOut{fbScalar(4, 64)}),
+		testField("b", arrowInt, fbTableOut{fbScalar(4, 8), fbScalar(1, 1)}),
+	}
+	batch := testBatch{
+		length: 3,
+		//name, point, px, py, x, u, b
+		nodes: []int64{3, 0, 3, 0, 3, 0, 3, 0, 3, 1, 3, 0, 3, 0},
+		buffers: [][]byte{
+			nil, le(4, 0, 1, 2, 3), []byte("abc"),
+			nil,
+			nil, le(4, 1, 2, 3),
+			nil, le(4, 4, 5, 6),
+			//the second x is null, the value under a null can be anything
+			{0x05}, le(4, 10, 12345, -30),
+			nil, le(8, 1, math.MaxInt64, 2),
+			nil, le(1, -1, 127, -128),
+		},
+	}
+	batch2 := batch
+	batch2.nodes = append([]int64{}, batch.nodes...)
+	batch2.nodes[9] = 0
+	batch2.buffers = append([][]byte{}, batch.buffers...)
+	batch2.buffers[8] = nil
+
+	for _, legacy := range []bool{false, true} {
+		stream := testStream(fields, []testBatch{batch, batch2}, legacy)
+		cases := []struct {
+			column string
+			opts   ArrowOptions
+			want   []int
+		}{
+			{"x", ArrowOptions{Nulls: CellSkip}, []int{10, -30, 10, 12345, -30}},
+			{"2", ArrowOptions{Nulls: CellDefault, Default: -1}, []int{10, -1, -30, 10, 12345, -30}},
+			{"b", ArrowOptions{}, []int{-1, 127, -128, -1, 127, -128}},
+		}
+		for _, c := range cases {
+			v := Intvector{}
+			if err := v.ReadArrow(bytes.NewReader(stream), c.column, c.opts); err != nil || !reflect.DeepEqual(v.vec, c.want) {
+				t.Errorf("ArrowColumns Test failed : %s legacy %v want %v got %v, %v", c.column, legacy, c.want, v.vec, err)
+			}
+		}
+
+		v := Intvector{}
+		v.Insert(7)
+		if err := v.ReadArrow(bytes.NewReader(stream), "x", ArrowOptions{}); !errors.Is(err, ErrNull) || err.Error() != "Row 1: Null value" {
+			t.Errorf("ArrowColumns Test failed : want ErrNull at row 1 got %v", err)
+		}
+		if !reflect.DeepEqual(v.vec, []int{7}) {
+			t.Errorf("ArrowColumns Test failed : want the vector untouched got %v", v.vec)
+		}
+		if err := v.ReadArrow(bytes.NewReader(stream), "u", ArrowOptions{}); math.MaxInt == math.MaxInt64 && err != nil {
+			t.Errorf("ArrowColumns Test failed : want the unsigned column got %v", err)
+		}
+		if err := v.ReadArrow(bytes.NewReader(stream), "name", ArrowOptions{}); err == nil {
+			t.Error("ArrowColumns Test failed : want an error for a string column")
+		}
+		if err := v.ReadArrow(bytes.NewReader(stream), "missing", ArrowOptions{}); err != ErrNoColumn {
+			t.Errorf("ArrowColumns Test failed : want ErrNoColumn got %v", err)
+		}
+	}
+
+	//an unsigned value above the largest int64 overflows
+	fields = fbTables{testField("u", arrowInt, fbTableOut{fbScalar(4, 64)})}
+	stream := testStream(fields, []testBatch{{length: 1, nodes: []int64{1, 0}, buffers: [][]byte{nil, le(8, -1)}}}, false)
+	v := Intvector{}
+	if err := v.ReadArrow(bytes.NewReader(stream), "u", ArrowOptions{}); !errors.Is(err, ErrOverflow) {
+		t.Errorf("ArrowColumns Test failed : want ErrOverflow got %v", err)
+	}
+}
+
+func TestArrowUnsupported(t *testing.T) {
+	int64Type := fbTableOut{fbScalar(4, 64), fbScalar(1, 1)}
+	dictionary := testField("d", arrowInt, int64Type)
+	dictionary[4] = fbRef(fbTableOut{fbScalar(8, 0), fbRef(int64Type)})
+	v := Intvector{}
+
+	stream := testStream(fbTables{dictionary}, nil, false)
+	if err := v.ReadArrow(bytes.NewReader(stream), "d", ArrowOptions{}); err == nil {
+		t.Error("ArrowUnsupported Test failed : want an error for a dictionary encoded column")
+	}
+	//a dictionary encoded column before the one that is read only has its indices in the batch
+	stream = testStream(fbTables{dictionary, testField("x", arrowInt, int64Type)},
+		[]testBatch{{length: 1, nodes: []int64{1, 0, 1, 0}, buffers: [][]byte{nil, le(8, 0), nil, le(8, 5)}}}, false)
+	if err := v.ReadArrow(bytes.NewReader(stream), "x", ArrowOptions{}); err != nil || !reflect.DeepEqual(v.vec, []int{5}) {
+		t.Errorf("ArrowUnsupported Test failed : want [5] got %v, %v", v.vec, err)
+	}
+
+	var b bytes.Buffer
+	big := fbTableOut{fbScalar(2, 1), fbRef(fbTables{testField("x", arrowInt, int64Type)})}
+	writeArrowMessage(&b, arrowSchemaMessage, big, nil)
+	if err := v.ReadArrow(&b, "x", ArrowOptions{}); err == nil {
+		t.Error("ArrowUnsupported Test failed : want an error for big endian data")
+	}
+}
+
+func TestArrowCorrupt(t *testing.T) {
+	v := Intvector{}
+	v.Insert(1, 2, 3)
+	var stream, file bytes.Buffer
+	v.WriteArrow(&stream, "x")
+	v.WriteArrowFile(&file, "x")
+
+	//every truncation of the stream within a message fails, the end of stream marker is optional
+	s := stream.Bytes()
+	schemaEnd := 8 + int(binary.LittleEndian.Uint32(s[4:]))
+	for i := 0; i < len(s)-len(arrowEOS); i++ {
+		u := Intvector{}
+		if err := u.ReadArrow(bytes.NewReader(s[:i]), "x", ArrowOptions{}); err == nil && i > 0 && i != schemaEnd {
+			t.Errorf("ArrowCorrupt Test failed : want an error for %d of %d bytes", i, len(s))
+		}
+	}
+	f := file.Bytes()
+	for i := 0; i < len(f); i++ {
+		u := Intvector{}
+		if err := u.ReadArrowFile(bytes.NewReader(f[:i]), int64(i), "x", ArrowOptions{}); err == nil {
+			t.Errorf("ArrowCorrupt Test failed : want an error for %d of %d bytes of the file", i, len(f))
+		}
+	}
+
+	//flipping any byte must not panic
+	for _, b := range [][]byte{s, f} {
+		for i := range b {
+			for _, x := range []byte{0x01, 0x80, 0xFF} {
+				c := append([]byte{}, b...)
+				c[i] ^= x
+				u := Intvector{}
+				u.ReadArrow(bytes.NewReader(c), "x", ArrowOptions{})
+				u.ReadArrowFile(bytes.NewReader(c), int64(len(c)), "x", ArrowOptions{})
+			}
+		}
+	}
+
+	if err := v.ReadArrow(bytes.NewReader(nil), "x", ArrowOptions{}); err == nil {
+		t.Error("ArrowCorrupt Test failed : want an error for an empty stream")
+	}
+	if err := v.ReadArrow(io.MultiReader(bytes.NewReader(s[:len(s)-8]), bytes.NewReader(s[8:])), "x", ArrowOptions{}); err == nil {
+		t.Error("ArrowCorrupt Test failed : want an error for a second schema")
+	}
+}
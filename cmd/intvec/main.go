@@ -0,0 +1,479 @@
+//Command intvec inspects and converts vectors without writing Go.
+//
+//Usage:
+//	intvec decode  [-to json|csv]                   binary on stdin to text on stdout
+//	intvec encode  [-from json|csv]                 text on stdin to binary on stdout
+//	intvec convert [-from format] [-to format]      between binary, csv and json
+//	intvec stats   [-from format] [-bins n]         size, min, max, mean, median, mode and a histogram
+//	intvec hash    [-from format]                   the Hash of the vector
+//	intvec verify  [-from format] hash              exits with 1 if the Hash of the vector is not hash
+//	intvec sort    [-from format] [-to format]      the vector in ascending order
+//	intvec uniq    [-from format] [-to format]      the vector without repeated values
+//	intvec diff    [-from format] [-to text|binary] a b
+//
+//Vectors are read from stdin and written to stdout, so the commands can be chained in a pipeline,
+//the binary format is the one of Serialized. diff reads the files a and b instead, - is stdin, and writes
+//the patch from a to b. Like diff(1) it exits with 1 if they differ, every command exits with 2 on errors
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"math/bits"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ankitshah86/intvector"
+)
+
+//errUsage is returned after the usage has been printed for invalid arguments
+var errUsage = errors.New("Invalid usage")
+
+//errFailed makes the command exit with 1 without an error message, for verify and diff
+var errFailed = errors.New("Failed")
+
+//histogramWidth is the length of the longest bar of the histogram
+const histogramWidth = 40
+
+const usage = `usage: intvec <command> [flags] [args]
+
+commands:
+  decode   binary to text
+  encode   text to binary
+  convert  between binary, csv and json
+  stats    size, min, max, mean, median, mode and a histogram
+  hash     the hash of the vector
+  verify   check the hash of the vector
+  sort     sort the vector
+  uniq     remove repeated values
+  diff     the patch between two vectors
+
+run intvec <command> -h for the flags of a command
+`
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+//run runs the command in args and returns the exit status
+func run(args []string, stdin io.Reader, stdout io.Writer, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(stderr, usage)
+		return 2
+	}
+
+	var cmd func(args []string, fs *flag.FlagSet, stdin io.Reader, stdout io.Writer) error
+	switch args[0] {
+	case "decode":
+		cmd = decode
+	case "encode":
+		cmd = encode
+	case "convert":
+		cmd = convert
+	case "stats":
+		cmd = stats
+	case "hash":
+		cmd = hash
+	case "verify":
+		cmd = verify
+	case "sort":
+		cmd = sortCmd
+	case "uniq":
+		cmd = uniq
+	case "diff":
+		cmd = diff
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	default:
+		fmt.Fprintf(stderr, "intvec: unknown command %q\n\n%s", args[0], usage)
+		return 2
+	}
+
+	fs := flag.NewFlagSet("intvec "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	err := cmd(args[1:], fs, stdin, stdout)
+	switch {
+	case err == nil:
+		return 0
+	case err == flag.ErrHelp:
+		return 0
+	case err == errFailed:
+		return 1
+	case err != errUsage:
+		fmt.Fprintf(stderr, "intvec %s: %v\n", args[0], err)
+	}
+	return 2
+}
+
+//parse parses the flags and checks the number of positional arguments
+func parse(fs *flag.FlagSet, args []string, nargs int) error {
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return err
+		}
+		return errUsage
+	}
+	if fs.NArg() != nargs {
+		fmt.Fprintf(fs.Output(), "%s: want %d arguments got %d\n", fs.Name(), nargs, fs.NArg())
+		fs.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+func decode(args []string, fs *flag.FlagSet, stdin io.Reader, stdout io.Writer) error {
+	to := fs.String("to", "json", "output `format`, json or csv")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *to == "binary" {
+		return errors.New("Use convert or encode to write binary")
+	}
+	return pipe(stdin, stdout, "binary", *to, nil)
+}
+
+func encode(args []string, fs *flag.FlagSet, stdin io.Reader, stdout io.Writer) error {
+	from := fs.String("from", "", "input `format`, json or csv, detected from the input if not set")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *from == "binary" {
+		return errors.New("Use convert or decode to read binary")
+	}
+	return pipe(stdin, stdout, *from, "binary", nil)
+}
+
+func convert(args []string, fs *flag.FlagSet, stdin io.Reader, stdout io.Writer) error {
+	from := fs.String("from", "binary", "input `format`, binary, csv or json")
+	to := fs.String("to", "json", "output `format`, binary, csv or json")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	return pipe(stdin, stdout, *from, *to, nil)
+}
+
+func sortCmd(args []string, fs *flag.FlagSet, stdin io.Reader, stdout io.Writer) error {
+	from := fs.String("from", "binary", "input `format`, binary, csv or json")
+	to := fs.String("to", "", "output `format`, the input format if not set")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	return pipe(stdin, stdout, *from, *to, (*intvector.Intvector).Sort)
+}
+
+func uniq(args []string, fs *flag.FlagSet, stdin io.Reader, stdout io.Writer) error {
+	from := fs.String("from", "binary", "input `format`, binary, csv or json")
+	to := fs.String("to", "", "output `format`, the input format if not set")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	return pipe(stdin, stdout, *from, *to, (*intvector.Intvector).MakeUnique)
+}
+
+//pipe reads a vector from r, applies f if it is not nil and writes the vector to w.
+//The output format is the input format if to is empty
+func pipe(r io.Reader, w io.Writer, from string, to string, f func(v *intvector.Intvector)) error {
+	if to == "" {
+		to = from
+	}
+	if err := checkFormat(to); err != nil {
+		return err
+	}
+	v, err := read(r, from)
+	if err != nil {
+		return err
+	}
+	if f != nil {
+		f(v)
+	}
+	return write(w, v, to)
+}
+
+func hash(args []string, fs *flag.FlagSet, stdin io.Reader, stdout io.Writer) error {
+	from := fs.String("from", "binary", "input `format`, binary, csv or json")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	v, err := read(stdin, *from)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(stdout, v.Hash())
+	return err
+}
+
+func verify(args []string, fs *flag.FlagSet, stdin io.Reader, stdout io.Writer) error {
+	from := fs.String("from", "binary", "input `format`, binary, csv or json")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+	v, err := read(stdin, *from)
+	if err != nil {
+		return err
+	}
+	want, got := strings.ToLower(fs.Arg(0)), v.Hash()
+	if want != got {
+		fmt.Fprintf(stdout, "MISMATCH want %s got %s\n", want, got)
+		return errFailed
+	}
+	_, err = fmt.Fprintln(stdout, "OK")
+	return err
+}
+
+func diff(args []string, fs *flag.FlagSet, stdin io.Reader, stdout io.Writer) error {
+	from := fs.String("from", "binary", "input `format`, binary, csv or json")
+	to := fs.String("to", "text", "output `format` of the patch, text or binary")
+	if err := parse(fs, args, 2); err != nil {
+		return err
+	}
+	if *to != "text" && *to != "binary" {
+		return fmt.Errorf("Unknown patch format %q", *to)
+	}
+	if fs.Arg(0) == "-" && fs.Arg(1) == "-" {
+		return errors.New("Only one of the vectors can be read from stdin")
+	}
+
+	var vs [2]*intvector.Intvector
+	for i := range vs {
+		r := stdin
+		if name := fs.Arg(i); name != "-" {
+			f, err := os.Open(name)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		v, err := read(r, *from)
+		if err != nil {
+			return fmt.Errorf("%s: %v", fs.Arg(i), err)
+		}
+		vs[i] = v
+	}
+
+	p := intvector.Diff(vs[0], vs[1])
+	var err error
+	if *to == "binary" {
+		_, err = stdout.Write(p.Serialized())
+	} else {
+		_, err = fmt.Fprintln(stdout, p.String())
+	}
+	if err == nil && p.Distance() > 0 {
+		err = errFailed
+	}
+	return err
+}
+
+func stats(args []string, fs *flag.FlagSet, stdin io.Reader, stdout io.Writer) error {
+	from := fs.String("from", "binary", "input `format`, binary, csv or json")
+	bins := fs.Int("bins", 10, "largest `number` of bins of the histogram, 0 for none")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *bins < 0 {
+		return errors.New("The number of bins can not be negative")
+	}
+	v, err := read(stdin, *from)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(tw, "size\t%d\n", v.Size())
+	if v.IsEmpty() {
+		fmt.Fprint(tw, "min\t-\nmax\t-\nmean\t-\nmedian\t-\nmode\t-\n")
+		return tw.Flush()
+	}
+	min, _ := v.Min()
+	max, _ := v.Max()
+	fmt.Fprintf(tw, "min\t%d\nmax\t%d\n", min, max)
+	fmt.Fprintf(tw, "mean\t%s\nmedian\t%s\n", formatFloat(v.Mean()), formatFloat(v.Median()))
+	fmt.Fprintf(tw, "mode\t%s\n", mode(v))
+	if *bins > 0 {
+		fmt.Fprint(tw, "\n")
+		histogram(tw, v, min, max, *bins)
+	}
+	return tw.Flush()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+//mode returns the modes of a vector that is not empty separated by spaces
+func mode(v *intvector.Intvector) string {
+	if m, err := v.Mode(); err == nil {
+		return strconv.Itoa(m)
+	}
+	//more than one value has the highest frequency
+	modes, _ := v.Modes()
+	sort.Ints(modes)
+	s := make([]string, len(modes))
+	for i, m := range modes {
+		s[i] = strconv.Itoa(m)
+	}
+	return strings.Join(s, " ")
+}
+
+//histogram writes a histogram of at most bins bins of equal width covering min to max, each as a line
+//with the range, the count and a bar
+func histogram(w io.Writer, v *intvector.Intvector, min int, max int, bins int) {
+	//the arithmetic is unsigned so that the span of values as far apart as math.MinInt and math.MaxInt does not overflow,
+	//a single bin over that span would be 1<<64 wide, so the width saturates and the last bin takes what is left
+	span := uint64(max) - uint64(min)
+	width, carry := bits.Add64(span/uint64(bins), 1, 0)
+	if carry != 0 {
+		width = math.MaxUint64
+	}
+	n := span/width + 1
+	if n > uint64(bins) {
+		n = uint64(bins)
+	}
+	counts := make([]int, n)
+	for i := 0; i < v.Size(); i++ {
+		x, _ := v.At(i)
+		b := (uint64(x) - uint64(min)) / width
+		if b >= n {
+			b = n - 1
+		}
+		counts[b]++
+	}
+
+	most := 0
+	for _, c := range counts {
+		if c > most {
+			most = c
+		}
+	}
+	for i, c := range counts {
+		//the bounds are built in uint64 as well, they wrap around to the right ints
+		lo := uint64(min) + uint64(i)*width
+		hi := uint64(max)
+		if i < len(counts)-1 {
+			hi = lo + width - 1
+		}
+		label := strconv.Itoa(int(lo))
+		if lo != hi {
+			label += ".." + strconv.Itoa(int(hi))
+		}
+		if bar := strings.Repeat("#", c*histogramWidth/most); bar != "" {
+			fmt.Fprintf(w, "%s\t%d\t%s\n", label, c, bar)
+		} else {
+			fmt.Fprintf(w, "%s\t%d\n", label, c)
+		}
+	}
+}
+
+//checkFormat returns an error if the format is unknown
+func checkFormat(format string) error {
+	switch format {
+	case "binary", "csv", "json":
+		return nil
+	}
+	return fmt.Errorf("Unknown format %q", format)
+}
+
+//read reads a vector in the format from r, a format of "" detects json or csv from the input
+func read(r io.Reader, format string) (*intvector.Intvector, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if format == "" {
+		format = "csv"
+		if t := bytes.TrimSpace(b); len(t) > 0 && t[0] == '[' {
+			format = "json"
+		}
+	}
+
+	v := &intvector.Intvector{}
+	switch format {
+	case "binary":
+		//an empty vector is serialized to no bytes at all
+		if len(b) > 0 {
+			if err := v.DeserializeFrom(b, false); err != nil {
+				return nil, err
+			}
+		}
+	case "json":
+		var s []int
+		if err := json.Unmarshal(b, &s); err != nil {
+			return nil, err
+		}
+		v.Insert(s...)
+	case "csv":
+		s, err := readCSV(b)
+		if err != nil {
+			return nil, err
+		}
+		v.Insert(s...)
+	default:
+		return nil, checkFormat(format)
+	}
+	return v, nil
+}
+
+//readCSV returns the integers of all the cells in reading order, blank cells are skipped
+func readCSV(b []byte) ([]int, error) {
+	cr := csv.NewReader(bytes.NewReader(b))
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	var s []int
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return s, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		for i, cell := range record {
+			cell = strings.TrimSpace(cell)
+			if cell == "" {
+				continue
+			}
+			n, err := strconv.Atoi(cell)
+			if err != nil {
+				line, _ := cr.FieldPos(i)
+				return nil, fmt.Errorf("line %d: %q is not an integer", line, cell)
+			}
+			s = append(s, n)
+		}
+	}
+}
+
+//write writes the vector in the format to w, the text formats end with a newline
+func write(w io.Writer, v *intvector.Intvector, format string) error {
+	s := make([]int, v.Size())
+	for i := range s {
+		s[i], _ = v.At(i)
+	}
+
+	var b []byte
+	switch format {
+	case "binary":
+		b = v.Serialized()
+	case "json":
+		b, _ = json.Marshal(s)
+		b = append(b, '\n')
+	case "csv":
+		for _, n := range s {
+			b = strconv.AppendInt(b, int64(n), 10)
+			b = append(b, '\n')
+		}
+	default:
+		return checkFormat(format)
+	}
+	_, err := w.Write(b)
+	return err
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ankitshah86/intvector"
+)
+
+//intvec runs the command with the input and returns the exit status, stdout and stderr
+func intvec(in string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(in), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+//blob returns the serialized form of the integers
+func blob(s ...int) string {
+	v := intvector.Intvector{}
+	v.Insert(s...)
+	return string(v.Serialized())
+}
+
+func TestConvert(t *testing.T) {
+	cases := []struct {
+		in   string
+		args []string
+		want string
+	}{
+		{blob(3, -1, 2), []string{"decode"}, "[3,-1,2]\n"},
+		{blob(3, -1, 2), []string{"decode", "-to", "csv"}, "3\n-1\n2\n"},
+		{"", []string{"decode"}, "[]\n"},
+		{" [3, -1, 2] ", []string{"encode"}, blob(3, -1, 2)},
+		{"3,-1\n\n2\n", []string{"encode"}, blob(3, -1, 2)},
+		{"3\n-1\n2\n", []string{"encode", "-from", "csv"}, blob(3, -1, 2)},
+		{"[]", []string{"encode"}, ""},
+		{"[1,2]", []string{"convert", "-from", "json", "-to", "csv"}, "1\n2\n"},
+		{"1,2", []string{"convert", "-from", "csv", "-to", "json"}, "[1,2]\n"},
+		{blob(3, 1, 3, 2), []string{"sort"}, blob(1, 2, 3, 3)},
+		{"[3,1,3,2]", []string{"sort", "-from", "json"}, "[1,2,3,3]\n"},
+		{"[3,1,3,2]", []string{"uniq", "-from", "json", "-to", "csv"}, "3\n1\n2\n"},
+	}
+	for _, c := range cases {
+		code, got, stderr := intvec(c.in, c.args...)
+		if code != 0 || got != c.want {
+			t.Errorf("Convert Test failed : %v want %q got %d %q %s", c.args, c.want, code, got, stderr)
+		}
+	}
+
+	//the commands compose, the output of one is the input of the next
+	_, b, _ := intvec("[5,1,5,3]", "encode")
+	_, b, _ = intvec(b, "uniq")
+	_, b, _ = intvec(b, "sort")
+	if _, got, _ := intvec(b, "decode"); got != "[1,3,5]\n" {
+		t.Errorf("Convert Test failed : want [1,3,5] got %q", got)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	cases := []struct {
+		in     string
+		args   []string
+		stderr string
+	}{
+		{"", nil, "usage"},
+		{"", []string{"nope"}, `unknown command "nope"`},
+		{"abc", []string{"decode"}, "Invalid length"},
+		{"1,x", []string{"encode", "-from", "csv"}, `line 1: "x" is not an integer`},
+		{"[1,", []string{"encode"}, "unexpected end of JSON input"},
+		{"", []string{"convert", "-to", "xml"}, `Unknown format "xml"`},
+		{"", []string{"verify"}, "want 1 arguments got 0"},
+		{"", []string{"stats", "-bogus"}, "flag provided but not defined"},
+	}
+	for _, c := range cases {
+		code, _, stderr := intvec(c.in, c.args...)
+		if code != 2 || !strings.Contains(stderr, c.stderr) {
+			t.Errorf("Errors Test failed : %v want 2 and %q got %d %q", c.args, c.stderr, code, stderr)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	v := intvector.Intvector{}
+	v.Insert(1, 2, 3)
+	want := v.Hash()
+
+	if code, got, _ := intvec(blob(1, 2, 3), "hash"); code != 0 || got != want+"\n" {
+		t.Errorf("Hash Test failed : want %s got %d %s", want, code, got)
+	}
+	if code, got, _ := intvec("[1,2,3]", "hash", "-from", "json"); code != 0 || got != want+"\n" {
+		t.Errorf("Hash Test failed : want %s got %d %s", want, code, got)
+	}
+	if code, got, _ := intvec(blob(1, 2, 3), "verify", strings.ToUpper(want)); code != 0 || got != "OK\n" {
+		t.Errorf("Verify Test failed : want OK got %d %s", code, got)
+	}
+	if code, got, _ := intvec(blob(1, 2, 4), "verify", want); code != 1 || !strings.HasPrefix(got, "MISMATCH") {
+		t.Errorf("Verify Test failed : want MISMATCH got %d %s", code, got)
+	}
+}
+
+func TestStats(t *testing.T) {
+	code, got, _ := intvec("[1,2,2,3,9,10]", "stats", "-from", "json", "-bins", "3")
+	want := `size    6
+min     1
+max     10
+mean    4.5
+median  2.5
+mode    2
+
+1..4   4  ########################################
+5..8   0
+9..10  2  ####################
+`
+	if code != 0 || got != want {
+		t.Errorf("Stats Test failed : want\n%s\ngot %d\n%s", want, code, got)
+	}
+
+	code, got, _ = intvec("[4,4,1,1]", "stats", "-from", "json", "-bins", "0")
+	if code != 0 || !strings.Contains(got, "mode    1 4\n") || strings.Contains(got, "#") {
+		t.Errorf("Stats Test failed : want modes 1 4 and no histogram got %d\n%s", code, got)
+	}
+
+	code, got, _ = intvec("", "stats")
+	if code != 0 || !strings.HasPrefix(got, "size    0\nmin     -\n") {
+		t.Errorf("Stats Test failed : got %d\n%s for an empty vector", code, got)
+	}
+
+	//the span of the values does not fit into an int
+	if code, _, stderr := intvec(blob(math.MinInt, math.MaxInt, 0), "stats"); code != 0 {
+		t.Errorf("Stats Test failed : got %d %s for extreme values", code, stderr)
+	}
+	//a single bin over the whole range of int is wider than the largest uint64
+	min, max := strconv.Itoa(math.MinInt), strconv.Itoa(math.MaxInt)
+	cases := []struct {
+		in   []int
+		bins string
+		want []string
+	}{
+		{[]int{math.MinInt, math.MaxInt, 0}, "1", []string{min + ".." + max + " 3"}},
+		{[]int{math.MinInt, math.MaxInt, 0, -1}, "2", []string{min + "..-1 2", "0.." + max + " 2"}},
+	}
+	for _, c := range cases {
+		code, got, stderr := intvec(blob(c.in...), "stats", "-bins", c.bins)
+		bars := []string{}
+		for _, line := range strings.Split(got, "\n") {
+			if f := strings.Fields(line); len(f) == 3 && strings.HasPrefix(f[2], "#") {
+				bars = append(bars, f[0]+" "+f[1])
+			}
+		}
+		if code != 0 || strings.Join(bars, "\n") != strings.Join(c.want, "\n") {
+			t.Errorf("Stats Test failed : want %v got %d %v %s", c.want, code, bars, stderr)
+		}
+	}
+}
+
+func TestDiff(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a")
+	b := filepath.Join(dir, "b")
+	os.WriteFile(a, []byte(blob(1, 2, 3, 4)), 0o644)
+	os.WriteFile(b, []byte(blob(1, 3, 4, 5)), 0o644)
+
+	code, got, stderr := intvec("", "diff", a, b)
+	if code != 1 || got != "=1 -2 =2 +5\n" {
+		t.Errorf("Diff Test failed : want 1 and the patch got %d %q %s", code, got, stderr)
+	}
+
+	//the binary patch applied to a gives b
+	code, got, _ = intvec(blob(1, 2, 3, 4), "diff", "-to", "binary", "-", b)
+	var p intvector.Patch
+	if err := p.DeserializeFrom([]byte(got)); code != 1 || err != nil {
+		t.Fatalf("Diff Test failed : got %d %v", code, err)
+	}
+	v := intvector.Intvector{}
+	v.Insert(1, 2, 3, 4)
+	if err := v.ApplyPatch(p); err != nil || string(v.Serialized()) != blob(1, 3, 4, 5) {
+		t.Errorf("Diff Test failed : the patch does not give b, %v", err)
+	}
+
+	if code, got, _ := intvec("", "diff", a, a); code != 0 || got != "=4\n" {
+		t.Errorf("Diff Test failed : want 0 for equal vectors got %d %q", code, got)
+	}
+	if code, _, _ := intvec("", "diff", a, filepath.Join(dir, "missing")); code != 2 {
+		t.Errorf("Diff Test failed : want 2 for a missing file got %d", code)
+	}
+}
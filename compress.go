@@ -0,0 +1,198 @@
+package intvector
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/lzw"
+	"compress/zlib"
+	"errors"
+	"io"
+)
+
+//Codec selects the compressor used by SerializeCompressed and WriteCompressed
+//It can be combined with Shuffle, e.g. CodecZlib|Shuffle
+type Codec byte
+
+const (
+	//CodecFlate is raw DEFLATE from compress/flate
+	CodecFlate Codec = iota + 1
+	//CodecGzip is DEFLATE in a gzip container from compress/gzip
+	CodecGzip
+	//CodecZlib is DEFLATE in a zlib container from compress/zlib
+	CodecZlib
+	//CodecLZW is LZW from compress/lzw, it ignores the level
+	CodecLZW
+
+	//Shuffle transposes the bytes of each block of words before compressing them, so that the mostly zero
+	//high order bytes of small numbers end up next to each other. This usually improves the ratio considerably
+	Shuffle Codec = 0x80
+)
+
+//compressedMagic starts every compressed vector, it is followed by a single Codec byte
+var compressedMagic = []byte("IVZ")
+
+//shuffleBlock is the number of words shuffled together, it bounds the memory used while streaming
+const shuffleBlock = 4096
+
+//SerializeCompressed returns the compressed version of Serialized
+//level is passed on to the compressor, e.g. flate.BestCompression, and is ignored for CodecLZW
+func (v *Intvector) SerializeCompressed(codec Codec, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := v.WriteCompressed(&buf, codec, level); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+//WriteCompressed writes the compressed version of Serialized to w, block by block
+func (v *Intvector) WriteCompressed(w io.Writer, codec Codec, level int) error {
+	var zw io.WriteCloser
+	var err error
+	switch codec &^ Shuffle {
+	case CodecFlate:
+		zw, err = flate.NewWriter(w, level)
+	case CodecGzip:
+		zw, err = gzip.NewWriterLevel(w, level)
+	case CodecZlib:
+		zw, err = zlib.NewWriterLevel(w, level)
+	case CodecLZW:
+		zw = lzw.NewWriter(w, lzw.MSB, 8)
+	default:
+		return errors.New("Unknown codec")
+	}
+	if err != nil {
+		return err
+	}
+
+	//none of the compressors write anything before their first Write, so the header still comes first
+	if _, err := w.Write(append(append([]byte{}, compressedMagic...), byte(codec))); err != nil {
+		return err
+	}
+
+	block := make([]byte, 0, 8*shuffleBlock)
+	tmp := make([]byte, 8*shuffleBlock)
+	for i := 0; i < len(v.vec); i += shuffleBlock {
+		end := i + shuffleBlock
+		if end > len(v.vec) {
+			end = len(v.vec)
+		}
+		block = appendWords(block[:0], v.vec[i:end])
+		if codec&Shuffle != 0 {
+			shuffle(tmp[:len(block)], block)
+			block, tmp = tmp[:len(block)], block
+		}
+		if _, err := zw.Write(block); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+//DeserializeCompressed parses the output of SerializeCompressed, the codec is detected from the header
+func (v *Intvector) DeserializeCompressed(b []byte, append bool) error {
+	return v.ReadCompressed(bytes.NewReader(b), append)
+}
+
+//ReadCompressed reads a vector written by WriteCompressed from r, the codec is detected from the header
+//The vector is only changed if the whole stream could be decoded
+func (v *Intvector) ReadCompressed(r io.Reader, append bool) error {
+	header := make([]byte, len(compressedMagic)+1)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return errors.New("Invalid header")
+	}
+	if !bytes.Equal(header[:len(compressedMagic)], compressedMagic) {
+		return errors.New("Invalid header")
+	}
+
+	codec := Codec(header[len(compressedMagic)])
+	var zr io.ReadCloser
+	var err error
+	switch codec &^ Shuffle {
+	case CodecFlate:
+		zr = flate.NewReader(r)
+	case CodecGzip:
+		zr, err = gzip.NewReader(r)
+	case CodecZlib:
+		zr, err = zlib.NewReader(r)
+	case CodecLZW:
+		zr = lzw.NewReader(r, lzw.MSB, 8)
+	default:
+		return errors.New("Unknown codec")
+	}
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	s := []int{}
+	block := make([]byte, 8*shuffleBlock)
+	tmp := make([]byte, 8*shuffleBlock)
+	for {
+		n, err := fill(zr, block)
+		if err != nil {
+			return err
+		}
+		if n%8 != 0 {
+			return errors.New("Invalid length")
+		}
+
+		words := block[:n]
+		if codec&Shuffle != 0 {
+			unshuffle(tmp[:n], words)
+			words = tmp[:n]
+		}
+		if s, err = decodeWords(s, words); err != nil {
+			return err
+		}
+		if n < len(block) {
+			break
+		}
+	}
+
+	if !append {
+		//replace the contents in a single step so that it is a single entry in the history
+		v.replace(s)
+	} else {
+		v.Insert(s...)
+	}
+	return nil
+}
+
+//fill reads from r until b is full or the stream ends cleanly
+//Unlike io.ReadFull it does not hide an io.ErrUnexpectedEOF returned by a decompressor for a truncated stream
+func fill(r io.Reader, b []byte) (int, error) {
+	n := 0
+	for n < len(b) {
+		c, err := r.Read(b[n:])
+		n += c
+		if err == io.EOF {
+			return n, nil
+		}
+		if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+//shuffle writes the bytes of the 8 byte words in src to dst grouped by their position in the word,
+//all first bytes, then all second bytes and so on
+func shuffle(dst []byte, src []byte) {
+	n := len(src) / 8
+	for i := 0; i < n; i++ {
+		for j := 0; j < 8; j++ {
+			dst[j*n+i] = src[i*8+j]
+		}
+	}
+}
+
+//unshuffle reverses shuffle
+func unshuffle(dst []byte, src []byte) {
+	n := len(src) / 8
+	for i := 0; i < n; i++ {
+		for j := 0; j < 8; j++ {
+			dst[i*8+j] = src[j*n+i]
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package intvector
+
+import (
+	"bytes"
+	"compress/flate"
+	"math/rand"
+	"testing"
+)
+
+func TestSerializeCompressed(t *testing.T) {
+	codecs := []Codec{CodecFlate, CodecGzip, CodecZlib, CodecLZW}
+	for _, size := range []int{0, 1, 100, shuffleBlock, shuffleBlock + 3, 3 * shuffleBlock} {
+		var s Intvector
+		for i := 0; i < size; i++ {
+			s.Push(rand.Intn(2000) - 1000)
+		}
+
+		for _, codec := range codecs {
+			for _, c := range []Codec{codec, codec | Shuffle} {
+				b, err := s.SerializeCompressed(c, flate.DefaultCompression)
+				if err != nil {
+					t.Errorf("SerializeCompressed Test failed : codec %#x returned error %s", c, err)
+					continue
+				}
+
+				var got Intvector
+				got.Push(42)
+				if err := got.DeserializeCompressed(b, false); err != nil {
+					t.Errorf("SerializeCompressed Test failed : codec %#x, DeserializeCompressed returned error %s", c, err)
+					continue
+				}
+				if got.Hash() != s.Hash() {
+					t.Errorf("SerializeCompressed Test failed : codec %#x did not round trip %d elements", c, size)
+				}
+			}
+		}
+	}
+}
+
+func TestSerializeCompressedRatio(t *testing.T) {
+	var s Intvector
+	for i := 0; i < 10000; i++ {
+		s.Push(rand.Intn(1 << 20))
+	}
+
+	plain, _ := s.SerializeCompressed(CodecZlib, flate.BestCompression)
+	shuffled, _ := s.SerializeCompressed(CodecZlib|Shuffle, flate.BestCompression)
+
+	if len(plain) >= len(s.Serialized()) {
+		t.Errorf("SerializeCompressed Test failed : compressed size %d is not smaller than %d", len(plain), len(s.Serialized()))
+	}
+	if len(shuffled) >= len(plain) {
+		t.Errorf("SerializeCompressed Test failed : shuffled size %d is not smaller than plain size %d", len(shuffled), len(plain))
+	}
+}
+
+func TestWriteCompressed(t *testing.T) {
+	var s Intvector
+	s.Insert(1, 2, 3)
+
+	var buf bytes.Buffer
+	if err := s.WriteCompressed(&buf, CodecGzip|Shuffle, flate.BestSpeed); err != nil {
+		t.Fatalf("WriteCompressed Test failed : returned error %s", err)
+	}
+
+	var got Intvector
+	got.Push(0)
+	if err := got.ReadCompressed(&buf, true); err != nil {
+		t.Fatalf("WriteCompressed Test failed : ReadCompressed returned error %s", err)
+	}
+	want := []int{0, 1, 2, 3}
+	for i, w := range want {
+		if g, _ := got.At(i); g != w {
+			t.Errorf("WriteCompressed Test failed : want %d at index %d got %d", w, i, g)
+		}
+	}
+
+	//replacing the contents is a single step in the history
+	buf.Reset()
+	s.WriteCompressed(&buf, CodecFlate, flate.BestSpeed)
+	got.EnableHistory(10)
+	if err := got.ReadCompressed(&buf, false); err != nil || got.Size() != 3 {
+		t.Fatalf("WriteCompressed Test failed : ReadCompressed returned error %v and size %d", err, got.Size())
+	}
+	got.Undo()
+	if got.Size() != 4 || got.CanUndo() {
+		t.Errorf("WriteCompressed Test failed : want a single undo back to size 4 got size %d", got.Size())
+	}
+
+	if err := s.WriteCompressed(&buf, Codec(9), 0); err == nil {
+		t.Error("WriteCompressed Test failed : should return error for unknown codec")
+	}
+	if err := s.WriteCompressed(&buf, CodecFlate, 42); err == nil {
+		t.Error("WriteCompressed Test failed : should return error for invalid level")
+	}
+}
+
+func TestDeserializeCompressed(t *testing.T) {
+	var s Intvector
+	s.Insert(5, 6, 7)
+
+	if err := s.DeserializeCompressed(s.Serialized(), false); err == nil {
+		t.Error("DeserializeCompressed Test failed : should return error for uncompressed input")
+	}
+
+	b, _ := s.SerializeCompressed(CodecZlib, flate.DefaultCompression)
+	if err := s.DeserializeCompressed(b[:len(b)-3], false); err == nil {
+		t.Error("DeserializeCompressed Test failed : should return error for truncated input")
+	}
+	if s.Size() != 3 {
+		t.Errorf("DeserializeCompressed Test failed : failed call changed the vector size to %d", s.Size())
+	}
+}
+
+func TestShuffle(t *testing.T) {
+	src := appendWords(nil, []int{1, 2, 3})
+	dst := make([]byte, len(src))
+	shuffle(dst, src)
+
+	//the low order bytes are the last of each big endian word, so they must end up last
+	if want := []byte{1, 2, 3}; !bytes.Equal(dst[21:], want) {
+		t.Errorf("Shuffle Test failed : want %v got %v", want, dst[21:])
+	}
+
+	back := make([]byte, len(src))
+	unshuffle(back, dst)
+	if !bytes.Equal(back, src) {
+		t.Errorf("Shuffle Test failed : unshuffle want %v got %v", src, back)
+	}
+}
@@ -0,0 +1,415 @@
+package intvector
+
+import (
+	"errors"
+	"sort"
+)
+
+//The CRDT types let replicas change their own copy while they are offline and merge the copies later.
+//Merging is commutative, associative and idempotent, so replicas that have merged the same changes in any order
+//hold the same contents. Every replica needs its own id, two replicas with the same id can lose changes.
+//Removed elements are kept as tombstones so that a merge can tell a removal from an element it has not seen yet
+
+//orTag identifies a single UniquePush on a replica
+type orTag struct {
+	replica uint64
+	counter uint64
+}
+
+//ORSet is an observed-remove set with the semantics of UniquePush and RemoveAll. A RemoveAll only removes the
+//pushes it has seen, so when a push and a removal of the same element happen concurrently the push wins
+type ORSet struct {
+	replica uint64
+	counter uint64
+	adds    map[int]map[orTag]bool
+	removed map[orTag]bool
+}
+
+//NewORSet returns an empty set for the replica with the given id
+func NewORSet(replica uint64) *ORSet {
+	return &ORSet{replica: replica, adds: make(map[int]map[orTag]bool), removed: make(map[orTag]bool)}
+}
+
+//UniquePush adds n if it is not already in the set and returns true if it was added
+func (s *ORSet) UniquePush(n int) bool {
+	if s.Contains(n) {
+		return false
+	}
+	s.counter++
+	s.adds[n] = map[orTag]bool{{replica: s.replica, counter: s.counter}: true}
+	return true
+}
+
+//RemoveAll removes n and returns the number of elements removed, which is 0 or 1
+func (s *ORSet) RemoveAll(n int) int {
+	tags, ok := s.adds[n]
+	if !ok {
+		return 0
+	}
+	for tag := range tags {
+		s.removed[tag] = true
+	}
+	delete(s.adds, n)
+	return 1
+}
+
+//Contains returns true if n is in the set
+func (s *ORSet) Contains(n int) bool {
+	return len(s.adds[n]) > 0
+}
+
+//Size returns the number of elements in the set
+func (s *ORSet) Size() int {
+	return len(s.adds)
+}
+
+//Merge adds the changes of other to the set, other is not changed
+func (s *ORSet) Merge(other *ORSet) {
+	for tag := range other.removed {
+		s.removed[tag] = true
+	}
+	for n, tags := range other.adds {
+		for tag := range tags {
+			if s.removed[tag] {
+				continue
+			}
+			if s.adds[n] == nil {
+				s.adds[n] = make(map[orTag]bool)
+			}
+			s.adds[n][tag] = true
+		}
+	}
+	for n, tags := range s.adds {
+		for tag := range tags {
+			if s.removed[tag] {
+				delete(tags, tag)
+			}
+		}
+		if len(tags) == 0 {
+			delete(s.adds, n)
+		}
+	}
+}
+
+//Intvector returns the elements of the set in ascending order
+func (s *ORSet) Intvector() *Intvector {
+	v := &Intvector{vec: make([]int, 0, len(s.adds))}
+	for n := range s.adds {
+		v.vec = append(v.vec, n)
+	}
+	sort.Ints(v.vec)
+	return v
+}
+
+//sortedTags returns the tags in a fixed order so that the serialized set does not depend on the map order
+func sortedTags(tags map[orTag]bool) []orTag {
+	s := make([]orTag, 0, len(tags))
+	for tag := range tags {
+		s = append(s, tag)
+	}
+	sort.Slice(s, func(i, j int) bool {
+		return s[i].replica < s[j].replica || s[i].replica == s[j].replica && s[i].counter < s[j].counter
+	})
+	return s
+}
+
+//Serialized returns the set as a slice of bytes so it can be sent to other replicas
+//The layout is the replica id, the counter, the number of pushes, every push as the element and its tag,
+//the number of tombstones and every tombstone tag, a tag is a replica id and a counter, all as 8 byte big endian words
+func (s *ORSet) Serialized() []byte {
+	b := appendUint64(nil, s.replica)
+	b = appendUint64(b, s.counter)
+
+	elems := s.Intvector().vec
+	count := 0
+	for _, n := range elems {
+		count += len(s.adds[n])
+	}
+	b = appendWord(b, count)
+	for _, n := range elems {
+		for _, tag := range sortedTags(s.adds[n]) {
+			b = appendWord(b, n)
+			b = appendUint64(b, tag.replica)
+			b = appendUint64(b, tag.counter)
+		}
+	}
+
+	b = appendWord(b, len(s.removed))
+	for _, tag := range sortedTags(s.removed) {
+		b = appendUint64(b, tag.replica)
+		b = appendUint64(b, tag.counter)
+	}
+	return b
+}
+
+//DeserializeFrom replaces the set with the one encoded in b by Serialized, including its replica id
+func (s *ORSet) DeserializeFrom(b []byte) error {
+	r := wordReader{b: b}
+	var err error
+	tmp := NewORSet(0)
+	if tmp.replica, err = r.uint64(); err != nil {
+		return err
+	}
+	if tmp.counter, err = r.uint64(); err != nil {
+		return err
+	}
+
+	count, err := r.word()
+	if err != nil {
+		return err
+	}
+	if count < 0 || count > len(r.b)/24 {
+		return errors.New("Invalid element count")
+	}
+	for i := 0; i < count; i++ {
+		n, _ := r.word()
+		var tag orTag
+		tag.replica, _ = r.uint64()
+		tag.counter, _ = r.uint64()
+		if tmp.adds[n] == nil {
+			tmp.adds[n] = make(map[orTag]bool)
+		}
+		tmp.adds[n][tag] = true
+	}
+
+	count, err = r.word()
+	if err != nil {
+		return err
+	}
+	if count < 0 || count != len(r.b)/16 {
+		return errors.New("Invalid tombstone count")
+	}
+	for i := 0; i < count; i++ {
+		var tag orTag
+		tag.replica, _ = r.uint64()
+		tag.counter, _ = r.uint64()
+		tmp.removed[tag] = true
+	}
+	if !r.done() {
+		return errors.New("Invalid length")
+	}
+
+	*s = *tmp
+	return nil
+}
+
+//rgaID identifies an element of an RGA by the Lamport clock of its insertion and the replica that inserted it
+//The zero id stands for the start of the sequence
+type rgaID struct {
+	clock   uint64
+	replica uint64
+}
+
+//less orders ids by their clock and then by their replica
+func (a rgaID) less(b rgaID) bool {
+	return a.clock < b.clock || a.clock == b.clock && a.replica < b.replica
+}
+
+//rgaNode is an element of an RGA, removed elements stay in the sequence as tombstones
+type rgaNode struct {
+	id      rgaID
+	after   rgaID
+	value   int
+	deleted bool
+}
+
+//RGA is a replicated growable array, an ordered sequence supporting Push, Unshift and RemoveAt.
+//Every element remembers the element it was inserted after, concurrent insertions after the same element
+//are ordered by their ids, so every replica ends up with the same order.
+//The operations take O(n) time where n counts the tombstones too
+type RGA struct {
+	replica uint64
+	clock   uint64
+	nodes   []rgaNode
+}
+
+//NewRGA returns an empty sequence for the replica with the given id
+func NewRGA(replica uint64) *RGA {
+	return &RGA{replica: replica}
+}
+
+//index returns the position of the node with the given id, or -1
+func (r *RGA) index(id rgaID) int {
+	for i, n := range r.nodes {
+		if n.id == id {
+			return i
+		}
+	}
+	return -1
+}
+
+//integrate inserts the node after the node it references, skipping the nodes that were inserted
+//after the same node concurrently and have a greater id, together with everything inserted after them
+func (r *RGA) integrate(node rgaNode) {
+	pos := 0
+	if node.after != (rgaID{}) {
+		pos = r.index(node.after) + 1
+	}
+	for pos < len(r.nodes) && node.id.less(r.nodes[pos].id) {
+		pos++
+	}
+	r.nodes = append(r.nodes, rgaNode{})
+	copy(r.nodes[pos+1:], r.nodes[pos:])
+	r.nodes[pos] = node
+}
+
+//insertAfter inserts value after the node with the given id
+func (r *RGA) insertAfter(after rgaID, value int) {
+	r.clock++
+	r.integrate(rgaNode{id: rgaID{clock: r.clock, replica: r.replica}, after: after, value: value})
+}
+
+//Push inserts/pushes a new integer at the back of the sequence
+func (r *RGA) Push(s int) {
+	var after rgaID
+	if len(r.nodes) > 0 {
+		after = r.nodes[len(r.nodes)-1].id
+	}
+	r.insertAfter(after, s)
+}
+
+//Unshift inserts a new integer in the front of the sequence
+func (r *RGA) Unshift(s int) {
+	r.insertAfter(rgaID{}, s)
+}
+
+//visible returns the position of the idx-th element that is not removed, or -1
+func (r *RGA) visible(idx int) int {
+	if idx < 0 {
+		return -1
+	}
+	for i, n := range r.nodes {
+		if n.deleted {
+			continue
+		}
+		if idx == 0 {
+			return i
+		}
+		idx--
+	}
+	return -1
+}
+
+//RemoveAt removes the element at the given idx
+func (r *RGA) RemoveAt(idx int) error {
+	i := r.visible(idx)
+	if i < 0 {
+		return errors.New("Index out of bounds")
+	}
+	r.nodes[i].deleted = true
+	return nil
+}
+
+//At allows for accesing any element of the sequence
+func (r *RGA) At(idx int) (int, error) {
+	i := r.visible(idx)
+	if i < 0 {
+		return 0, errors.New("Index out of bounds")
+	}
+	return r.nodes[i].value, nil
+}
+
+//Size returns the number of elements in the sequence
+func (r *RGA) Size() int {
+	size := 0
+	for _, n := range r.nodes {
+		if !n.deleted {
+			size++
+		}
+	}
+	return size
+}
+
+//Merge adds the changes of other to the sequence, other is not changed
+func (r *RGA) Merge(other *RGA) {
+	if other.clock > r.clock {
+		r.clock = other.clock
+	}
+	//a node always comes after the node it references, so the referenced node is known when it is integrated
+	for _, n := range other.nodes {
+		if i := r.index(n.id); i >= 0 {
+			r.nodes[i].deleted = r.nodes[i].deleted || n.deleted
+		} else {
+			r.integrate(n)
+		}
+	}
+}
+
+//Intvector returns the elements of the sequence in order
+func (r *RGA) Intvector() *Intvector {
+	v := &Intvector{}
+	for _, n := range r.nodes {
+		if !n.deleted {
+			v.vec = append(v.vec, n.value)
+		}
+	}
+	return v
+}
+
+//Serialized returns the sequence as a slice of bytes so it can be sent to other replicas
+//The layout is the replica id, the clock, the number of nodes and every node in order as its id, the id
+//it was inserted after, its value and 1 if it was removed or 0 otherwise, all as 8 byte big endian words
+func (r *RGA) Serialized() []byte {
+	b := appendUint64(nil, r.replica)
+	b = appendUint64(b, r.clock)
+	b = appendWord(b, len(r.nodes))
+	for _, n := range r.nodes {
+		b = appendUint64(b, n.id.clock)
+		b = appendUint64(b, n.id.replica)
+		b = appendUint64(b, n.after.clock)
+		b = appendUint64(b, n.after.replica)
+		b = appendWord(b, n.value)
+		deleted := 0
+		if n.deleted {
+			deleted = 1
+		}
+		b = appendWord(b, deleted)
+	}
+	return b
+}
+
+//DeserializeFrom replaces the sequence with the one encoded in b by Serialized, including its replica id
+func (r *RGA) DeserializeFrom(b []byte) error {
+	wr := wordReader{b: b}
+	var err error
+	var tmp RGA
+	if tmp.replica, err = wr.uint64(); err != nil {
+		return err
+	}
+	if tmp.clock, err = wr.uint64(); err != nil {
+		return err
+	}
+	count, err := wr.word()
+	if err != nil {
+		return err
+	}
+	if count < 0 || count != len(wr.b)/48 {
+		return errors.New("Invalid node count")
+	}
+
+	seen := map[rgaID]bool{{}: true}
+	tmp.nodes = make([]rgaNode, count)
+	for i := range tmp.nodes {
+		n := &tmp.nodes[i]
+		n.id.clock, _ = wr.uint64()
+		n.id.replica, _ = wr.uint64()
+		n.after.clock, _ = wr.uint64()
+		n.after.replica, _ = wr.uint64()
+		if n.value, err = wr.word(); err != nil {
+			return err
+		}
+		deleted, _ := wr.word()
+		n.deleted = deleted != 0
+
+		if seen[n.id] || !seen[n.after] || n.id.clock > tmp.clock {
+			return errors.New("Invalid node")
+		}
+		seen[n.id] = true
+	}
+	if !wr.done() {
+		return errors.New("Invalid length")
+	}
+
+	*r = tmp
+	return nil
+}
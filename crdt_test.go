@@ -0,0 +1,161 @@
+package intvector
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestORSet(t *testing.T) {
+	a, b := NewORSet(1), NewORSet(2)
+	a.UniquePush(1)
+	a.UniquePush(2)
+	if a.UniquePush(1) {
+		t.Error("ORSet Test failed : want UniquePush of a present element to return false")
+	}
+	b.Merge(a)
+
+	//b removes 1 while a pushes it again after removing it, the new push was not seen by b so it wins
+	b.RemoveAll(1)
+	a.RemoveAll(1)
+	a.UniquePush(1)
+	b.UniquePush(3)
+	a.Merge(b)
+	b.Merge(a)
+
+	want := []int{1, 2, 3}
+	if !reflect.DeepEqual(a.Intvector().vec, want) || !reflect.DeepEqual(b.Intvector().vec, want) {
+		t.Errorf("ORSet Test failed : want %v got %v and %v", want, a.Intvector().vec, b.Intvector().vec)
+	}
+	if n := a.RemoveAll(7); n != 0 {
+		t.Errorf("ORSet Test failed : want 0 removed got %d", n)
+	}
+}
+
+func TestORSetConverge(t *testing.T) {
+	sets := []*ORSet{NewORSet(1), NewORSet(2), NewORSet(3)}
+	for round := 0; round < 20; round++ {
+		for _, s := range sets {
+			for i := 0; i < 10; i++ {
+				if rand.Intn(3) == 0 {
+					s.RemoveAll(rand.Intn(20))
+				} else {
+					s.UniquePush(rand.Intn(20))
+				}
+			}
+		}
+		//merge a random pair, in a random direction
+		i, j := rand.Intn(3), rand.Intn(3)
+		sets[i].Merge(sets[j])
+	}
+
+	//after everyone merged everyone in different orders all replicas are the same
+	sets[0].Merge(sets[1])
+	sets[2].Merge(sets[0])
+	sets[1].Merge(sets[2])
+	sets[0].Merge(sets[1])
+	for _, s := range sets[1:] {
+		if s.Intvector().Hash() != sets[0].Intvector().Hash() {
+			t.Fatalf("ORSetConverge Test failed : want %v got %v", sets[0].Intvector().vec, s.Intvector().vec)
+		}
+	}
+
+	var copy ORSet
+	if err := copy.DeserializeFrom(sets[0].Serialized()); err != nil {
+		t.Fatalf("ORSetConverge Test failed : %v", err)
+	}
+	if !reflect.DeepEqual(copy.Serialized(), sets[0].Serialized()) || copy.Intvector().Hash() != sets[0].Intvector().Hash() {
+		t.Error("ORSetConverge Test failed : the set does not round trip")
+	}
+	if err := copy.DeserializeFrom(sets[0].Serialized()[:20]); err == nil {
+		t.Error("ORSetConverge Test failed : want an error for a truncated set")
+	}
+}
+
+func TestRGA(t *testing.T) {
+	a := NewRGA(1)
+	a.Push(1)
+	a.Push(2)
+	a.Unshift(0)
+
+	b := NewRGA(2)
+	b.Merge(a)
+
+	//concurrent edits on both replicas
+	a.Push(3)
+	a.RemoveAt(0)
+	b.Push(4)
+	b.Unshift(-1)
+	b.RemoveAt(2)
+
+	a.Merge(b)
+	b.Merge(a)
+	if a.Intvector().Hash() != b.Intvector().Hash() {
+		t.Fatalf("RGA Test failed : want the same contents got %v and %v", a.Intvector().vec, b.Intvector().vec)
+	}
+	//b pushed 4 with the higher replica id, so it comes first after 2
+	want := []int{-1, 2, 4, 3}
+	if !reflect.DeepEqual(a.Intvector().vec, want) {
+		t.Errorf("RGA Test failed : want %v got %v", want, a.Intvector().vec)
+	}
+	if a.Size() != len(want) {
+		t.Errorf("RGA Test failed : want size %d got %d", len(want), a.Size())
+	}
+	if got, _ := a.At(1); got != 2 {
+		t.Errorf("RGA Test failed : want 2 at index 1 got %d", got)
+	}
+	if err := a.RemoveAt(4); err == nil {
+		t.Error("RGA Test failed : want an error for an index out of bounds")
+	}
+}
+
+func TestRGAConverge(t *testing.T) {
+	rgas := []*RGA{NewRGA(1), NewRGA(2), NewRGA(3)}
+	for round := 0; round < 30; round++ {
+		for _, r := range rgas {
+			for i := 0; i < 5; i++ {
+				switch rand.Intn(3) {
+				case 0:
+					r.Push(rand.Intn(100))
+				case 1:
+					r.Unshift(rand.Intn(100))
+				default:
+					if r.Size() > 0 {
+						r.RemoveAt(rand.Intn(r.Size()))
+					}
+				}
+			}
+		}
+		i, j := rand.Intn(3), rand.Intn(3)
+		rgas[i].Merge(rgas[j])
+	}
+
+	rgas[0].Merge(rgas[1])
+	rgas[2].Merge(rgas[0])
+	rgas[1].Merge(rgas[2])
+	rgas[0].Merge(rgas[1])
+	for _, r := range rgas[1:] {
+		if r.Intvector().Hash() != rgas[0].Intvector().Hash() {
+			t.Fatalf("RGAConverge Test failed : want %v got %v", rgas[0].Intvector().vec, r.Intvector().vec)
+		}
+	}
+
+	//merging again changes nothing
+	hash := rgas[0].Intvector().Hash()
+	rgas[0].Merge(rgas[2])
+	if rgas[0].Intvector().Hash() != hash {
+		t.Error("RGAConverge Test failed : merging twice changed the sequence")
+	}
+
+	var copy RGA
+	if err := copy.DeserializeFrom(rgas[0].Serialized()); err != nil {
+		t.Fatalf("RGAConverge Test failed : %v", err)
+	}
+	if copy.Intvector().Hash() != hash {
+		t.Error("RGAConverge Test failed : the sequence does not round trip")
+	}
+	b := rgas[0].Serialized()
+	if err := copy.DeserializeFrom(b[:len(b)-8]); err == nil {
+		t.Error("RGAConverge Test failed : want an error for a truncated sequence")
+	}
+}
@@ -0,0 +1,174 @@
+package intvector
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//ErrNoColumn is returned by ReadCSV if the column is neither in the header nor a valid index
+var ErrNoColumn = errors.New("Column not found")
+
+//CellPolicy decides what ReadCSV does with a blank or invalid cell and what ReadArrow and ReadArrowFile do with a null
+type CellPolicy int
+
+const (
+	//CellError stops reading with a *CSVError, or ErrNull for a null in Arrow
+	CellError CellPolicy = iota
+	//CellSkip leaves the cell out
+	CellSkip
+	//CellDefault reads the cell as CSVOptions.Default, or ArrowOptions.Default for a null in Arrow
+	CellDefault
+)
+
+//CSVOptions configures ReadCSV and WriteCSV, the zero value is comma separated values without a header
+//that fail on blank and invalid cells
+type CSVOptions struct {
+	//Comma is the delimiter, ',' if it is 0. Use '\t' for TSV
+	Comma rune
+	//Comment makes lines starting with it comments that are ignored, if it is not 0
+	Comment rune
+	//Header is true if the first line has the names of the columns
+	Header bool
+	//Blank is the policy for cells that are empty or only spaces, a line without the column counts as blank
+	Blank CellPolicy
+	//Invalid is the policy for cells that are not an integer
+	Invalid CellPolicy
+	//Default is the value of cells with the policy CellDefault
+	Default int
+	//Append appends the values to the vector instead of replacing its contents
+	Append bool
+}
+
+//CSVError is a cell that could not be read, Line and Column are 1-based and Column counts bytes like in csv.ParseError.
+//Column is 0 if the line does not have the cell at all
+type CSVError struct {
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *CSVError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *CSVError) Unwrap() error {
+	return e.Err
+}
+
+//reader returns a csv reader configured by the options
+func (opts CSVOptions) reader(r io.Reader) *csv.Reader {
+	cr := csv.NewReader(r)
+	if opts.Comma != 0 {
+		cr.Comma = opts.Comma
+	}
+	cr.Comment = opts.Comment
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	return cr
+}
+
+//ReadCSV reads the integers of one column of r, one per line. Text with one integer per line is
+//read with the column "0".
+//The column is first looked up by name in the header and otherwise taken as a 0-based index, so a header
+//can name a column with a number. Spaces around a value are ignored and empty lines are skipped.
+//The input is read one line at a time and the vector is only changed once all of it has been read,
+//so on an error it is left as it was
+func (v *Intvector) ReadCSV(r io.Reader, column string, opts CSVOptions) error {
+	cr := opts.reader(r)
+
+	col := -1
+	if opts.Header {
+		header, err := cr.Read()
+		if err == io.EOF {
+			return ErrNoColumn
+		}
+		if err != nil {
+			return err
+		}
+		for i, name := range header {
+			if strings.TrimSpace(name) == column {
+				col = i
+				break
+			}
+		}
+	}
+	if col < 0 {
+		i, err := strconv.Atoi(column)
+		if err != nil || i < 0 {
+			return ErrNoColumn
+		}
+		col = i
+	}
+
+	var s []int
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		cell, policy, cause := "", opts.Blank, errors.New("Blank cell")
+		if col < len(record) {
+			cell = strings.TrimSpace(record[col])
+		}
+		if cell != "" {
+			n, err := strconv.Atoi(cell)
+			if err == nil {
+				s = append(s, n)
+				continue
+			}
+			policy, cause = opts.Invalid, fmt.Errorf("Invalid integer %q", cell)
+		}
+
+		switch policy {
+		case CellSkip:
+		case CellDefault:
+			s = append(s, opts.Default)
+		default:
+			e := &CSVError{Err: cause}
+			if col < len(record) {
+				e.Line, e.Column = cr.FieldPos(col)
+			} else {
+				e.Line, _ = cr.FieldPos(0)
+			}
+			return e
+		}
+	}
+
+	if !opts.Append {
+		v.replace(s)
+	} else {
+		v.Insert(s...)
+	}
+	return nil
+}
+
+//WriteCSV writes the vector as a single column, one integer per line. If opts.Header is true
+//the first line is the column name
+func (v *Intvector) WriteCSV(w io.Writer, column string, opts CSVOptions) error {
+	cw := csv.NewWriter(w)
+	if opts.Comma != 0 {
+		cw.Comma = opts.Comma
+	}
+	if opts.Header {
+		if err := cw.Write([]string{column}); err != nil {
+			return err
+		}
+	}
+	record := make([]string, 1)
+	for _, n := range v.vec {
+		record[0] = strconv.Itoa(n)
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,111 @@
+package intvector
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadCSV(t *testing.T) {
+	in := "id,name,count\n1,a,10\n2,b, 20 \n3,c,\n4,d,x\n5,e\n"
+	cases := []struct {
+		column string
+		opts   CSVOptions
+		want   []int
+	}{
+		{"count", CSVOptions{Header: true, Blank: CellSkip, Invalid: CellSkip}, []int{10, 20}},
+		{"count", CSVOptions{Header: true, Blank: CellDefault, Invalid: CellDefault, Default: -1}, []int{10, 20, -1, -1, -1}},
+		{"2", CSVOptions{Header: true, Blank: CellSkip, Invalid: CellDefault}, []int{10, 20, 0}},
+		{"id", CSVOptions{Header: true}, []int{1, 2, 3, 4, 5}},
+		{"0", CSVOptions{Header: true}, []int{1, 2, 3, 4, 5}},
+	}
+	for _, c := range cases {
+		v := Intvector{}
+		v.Insert(99)
+		if err := v.ReadCSV(strings.NewReader(in), c.column, c.opts); err != nil || !reflect.DeepEqual(v.vec, c.want) {
+			t.Errorf("ReadCSV Test failed : %s %+v want %v got %v, %v", c.column, c.opts, c.want, v.vec, err)
+		}
+	}
+
+	v := Intvector{}
+	v.Insert(7)
+	err := v.ReadCSV(strings.NewReader("5\n-3\n\n 8\n"), "0", CSVOptions{Append: true})
+	if err != nil || !reflect.DeepEqual(v.vec, []int{7, 5, -3, 8}) {
+		t.Errorf("ReadCSV Test failed : want [7 5 -3 8] got %v, %v", v.vec, err)
+	}
+
+	err = v.ReadCSV(strings.NewReader("# counts\na\tb\n1\t2\n3\t4\n"), "b", CSVOptions{Comma: '\t', Comment: '#', Header: true})
+	if err != nil || !reflect.DeepEqual(v.vec, []int{2, 4}) {
+		t.Errorf("ReadCSV Test failed : want [2 4] from TSV got %v, %v", v.vec, err)
+	}
+}
+
+func TestReadCSVErrors(t *testing.T) {
+	in := "id,name,count\n1,a,10\n2,b, 20 \n3,c,\n4,d,x\n5,e\n"
+	cases := []struct {
+		opts   CSVOptions
+		line   int
+		column int
+	}{
+		{CSVOptions{Header: true}, 4, 5},
+		{CSVOptions{Header: true, Blank: CellSkip}, 5, 5},
+	}
+	for _, c := range cases {
+		v := Intvector{}
+		v.Insert(99)
+		err := v.ReadCSV(strings.NewReader(in), "count", c.opts)
+		var e *CSVError
+		if !errors.As(err, &e) || e.Line != c.line || e.Column != c.column {
+			t.Errorf("ReadCSVErrors Test failed : %+v want line %d column %d got %v", c.opts, c.line, c.column, err)
+		}
+		if !reflect.DeepEqual(v.vec, []int{99}) {
+			t.Errorf("ReadCSVErrors Test failed : want the vector untouched got %v", v.vec)
+		}
+	}
+
+	//a line without the column is a blank cell
+	v := Intvector{}
+	var e *CSVError
+	if err := v.ReadCSV(strings.NewReader("a,b\n1,2\n3\n"), "b", CSVOptions{Header: true}); !errors.As(err, &e) || e.Line != 3 || e.Column != 0 {
+		t.Errorf("ReadCSVErrors Test failed : want line 3 column 0 got %v", err)
+	}
+	if err := v.ReadCSV(strings.NewReader(in), "missing", CSVOptions{Header: true}); err != ErrNoColumn {
+		t.Errorf("ReadCSVErrors Test failed : want ErrNoColumn got %v", err)
+	}
+	if err := v.ReadCSV(strings.NewReader(""), "a", CSVOptions{Header: true}); err != ErrNoColumn {
+		t.Errorf("ReadCSVErrors Test failed : want ErrNoColumn for no header got %v", err)
+	}
+	if err := v.ReadCSV(strings.NewReader("1,\"2\n"), "0", CSVOptions{}); err == nil {
+		t.Error("ReadCSVErrors Test failed : want an error for an unterminated quote")
+	}
+	if err := v.ReadCSV(strings.NewReader("x\n"), "0", CSVOptions{}); err == nil || err.Error() != `line 1, column 1: Invalid integer "x"` {
+		t.Errorf("ReadCSVErrors Test failed : got %v", err)
+	}
+}
+
+func TestWriteCSV(t *testing.T) {
+	v := Intvector{}
+	v.Insert(3, -1, 2)
+
+	var b bytes.Buffer
+	if err := v.WriteCSV(&b, "count", CSVOptions{Header: true}); err != nil || b.String() != "count\n3\n-1\n2\n" {
+		t.Errorf("WriteCSV Test failed : got %q, %v", b.String(), err)
+	}
+
+	//the delimiter forces quotes on the negative value
+	b.Reset()
+	if err := v.WriteCSV(&b, "", CSVOptions{Comma: '-'}); err != nil || b.String() != "3\n\"-1\"\n2\n" {
+		t.Errorf("WriteCSV Test failed : got %q, %v", b.String(), err)
+	}
+
+	for _, opts := range []CSVOptions{{}, {Header: true}, {Header: true, Comma: ';'}} {
+		b.Reset()
+		v.WriteCSV(&b, "n", opts)
+		u := Intvector{}
+		if err := u.ReadCSV(&b, "0", opts); err != nil || !reflect.DeepEqual(u.vec, v.vec) {
+			t.Errorf("WriteCSV Test failed : %+v want %v got %v, %v", opts, v.vec, u.vec, err)
+		}
+	}
+}
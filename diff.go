@@ -0,0 +1,445 @@
+package intvector
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+//DiffOp identifies what a DiffEdit does
+type DiffOp byte
+
+const (
+	//DiffKeep keeps Count elements
+	DiffKeep DiffOp = iota + 1
+	//DiffDelete deletes the Values
+	DiffDelete
+	//DiffInsert inserts the Values
+	DiffInsert
+)
+
+//DiffEdit is a run of elements that are kept, deleted or inserted
+type DiffEdit struct {
+	Op    DiffOp
+	Count int
+	//Values holds the deleted or inserted elements, it is nil for DiffKeep
+	Values []int
+}
+
+//Patch is an edit script that turns one vector into another, applied from the first element on
+type Patch []DiffEdit
+
+//Diff returns a minimal edit script that turns a into b, computed with Myers' algorithm in linear space.
+//It takes O((n+m)d) time for vectors of n and m elements that differ in d elements
+func Diff(a, b *Intvector) Patch {
+	d := differ{a: a.vec, b: b.vec}
+	d.compare(0, len(a.vec), 0, len(b.vec))
+	return d.patch
+}
+
+//differ holds the state of a Diff
+type differ struct {
+	a, b  []int
+	patch Patch
+	//vf and vb are the furthest reaching x on every diagonal of the forward and the backward search
+	vf, vb []int
+}
+
+//add appends count elements to the patch, merging them with the last run if it has the same op
+func (d *differ) add(op DiffOp, values []int, count int) {
+	if count == 0 {
+		return
+	}
+	if n := len(d.patch); n > 0 && d.patch[n-1].Op == op {
+		d.patch[n-1].Count += count
+		if op != DiffKeep {
+			d.patch[n-1].Values = append(d.patch[n-1].Values, values...)
+		}
+		return
+	}
+	e := DiffEdit{Op: op, Count: count}
+	if op != DiffKeep {
+		e.Values = append([]int{}, values...)
+	}
+	d.patch = append(d.patch, e)
+}
+
+//compare adds the edit script between a[aLo:aHi] and b[bLo:bHi] to the patch
+func (d *differ) compare(aLo, aHi, bLo, bHi int) {
+	//common prefix and suffix
+	prefix := 0
+	for aLo+prefix < aHi && bLo+prefix < bHi && d.a[aLo+prefix] == d.b[bLo+prefix] {
+		prefix++
+	}
+	suffix := 0
+	for aHi-suffix > aLo+prefix && bHi-suffix > bLo+prefix && d.a[aHi-suffix-1] == d.b[bHi-suffix-1] {
+		suffix++
+	}
+	d.add(DiffKeep, nil, prefix)
+	aLo, bLo = aLo+prefix, bLo+prefix
+	aHi, bHi = aHi-suffix, bHi-suffix
+
+	if aLo == aHi {
+		d.add(DiffInsert, d.b[bLo:bHi], bHi-bLo)
+	} else if bLo == bHi {
+		d.add(DiffDelete, d.a[aLo:aHi], aHi-aLo)
+	} else {
+		x, y, u, v := d.middleSnake(aLo, aHi, bLo, bHi)
+		d.compare(aLo, x, bLo, y)
+		d.add(DiffKeep, nil, u-x)
+		d.compare(u, aHi, v, bHi)
+	}
+	d.add(DiffKeep, nil, suffix)
+}
+
+//middleSnake runs the forward and the backward search until they meet and returns the snake where they do,
+//it goes from (x, y) to (u, v) in absolute positions. Both ranges must be non-empty
+func (d *differ) middleSnake(aLo, aHi, bLo, bHi int) (int, int, int, int) {
+	n, m := aHi-aLo, bHi-bLo
+	delta := n - m
+	odd := delta&1 != 0
+	max := (n + m + 1) / 2
+	offset := max + 1
+	if len(d.vf) < 2*offset+1 {
+		d.vf = make([]int, 2*offset+1)
+		d.vb = make([]int, 2*offset+1)
+	}
+	vf, vb := d.vf, d.vb
+	vf[offset+1], vb[offset+1] = 0, 0
+
+	for D := 0; D <= max; D++ {
+		for k := -D; k <= D; k += 2 {
+			var x int
+			if k == -D || k != D && vf[offset+k-1] < vf[offset+k+1] {
+				x = vf[offset+k+1]
+			} else {
+				x = vf[offset+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && d.a[aLo+x] == d.b[bLo+y] {
+				x++
+				y++
+			}
+			vf[offset+k] = x
+			if odd && k >= delta-(D-1) && k <= delta+(D-1) && x+vb[offset+delta-k] >= n {
+				return aLo + x0, bLo + y0, aLo + x, bLo + y
+			}
+		}
+		for k := -D; k <= D; k += 2 {
+			var x int
+			if k == -D || k != D && vb[offset+k-1] < vb[offset+k+1] {
+				x = vb[offset+k+1]
+			} else {
+				x = vb[offset+k-1] + 1
+			}
+			y := x - k
+			x0, y0 := x, y
+			for x < n && y < m && d.a[aHi-1-x] == d.b[bHi-1-y] {
+				x++
+				y++
+			}
+			vb[offset+k] = x
+			if !odd && delta-k >= -D && delta-k <= D && x+vf[offset+delta-k] >= n {
+				return aHi - x, bHi - y, aHi - x0, bHi - y0
+			}
+		}
+	}
+	//the searches always meet after at most max steps. Should they not, an empty snake after the end of a
+	//still splits the ranges into deleting all of a and inserting all of b, a correct if not shortest script
+	return aHi, bLo, aHi, bLo
+}
+
+//Len returns the number of elements the patch expects in the vector it is applied to
+func (p Patch) Len() int {
+	n := 0
+	for _, e := range p {
+		if e.Op != DiffInsert {
+			n += e.Count
+		}
+	}
+	return n
+}
+
+//Distance returns the number of deleted and inserted elements
+func (p Patch) Distance() int {
+	n := 0
+	for _, e := range p {
+		if e.Op != DiffKeep {
+			n += e.Count
+		}
+	}
+	return n
+}
+
+//apply returns the result of applying the patch to s, or an error if s is not the vector the patch was made for
+func (p Patch) apply(s []int) ([]int, error) {
+	res := make([]int, 0, len(s))
+	i := 0
+	for _, e := range p {
+		switch e.Op {
+		case DiffKeep:
+			if e.Count < 0 || e.Count > len(s)-i {
+				return nil, errors.New("Patch does not apply")
+			}
+			res = append(res, s[i:i+e.Count]...)
+			i += e.Count
+		case DiffDelete:
+			if len(e.Values) > len(s)-i {
+				return nil, errors.New("Patch does not apply")
+			}
+			for _, val := range e.Values {
+				if s[i] != val {
+					return nil, errors.New("Patch does not apply")
+				}
+				i++
+			}
+		case DiffInsert:
+			res = append(res, e.Values...)
+		default:
+			return nil, errors.New("Invalid patch")
+		}
+	}
+	if i != len(s) {
+		return nil, errors.New("Patch does not apply")
+	}
+	return res, nil
+}
+
+//ApplyPatch applies a patch made by Diff, the deleted and kept elements are checked first
+//and the vector is left unchanged if it is not the one the patch was made for
+func (v *Intvector) ApplyPatch(p Patch) error {
+	s, err := p.apply(v.vec)
+	if err != nil {
+		return err
+	}
+	v.replace(s)
+	return nil
+}
+
+//String returns the text form of the patch, the runs separated by spaces: "=n" keeps n elements,
+//"-1,2" deletes the elements 1 and 2 and "+3" inserts the element 3, for example "=2 -5 +7,-8 =1"
+func (p Patch) String() string {
+	var sb strings.Builder
+	for i, e := range p {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		switch e.Op {
+		case DiffKeep:
+			sb.WriteByte('=')
+			sb.WriteString(strconv.Itoa(e.Count))
+			continue
+		case DiffDelete:
+			sb.WriteByte('-')
+		case DiffInsert:
+			sb.WriteByte('+')
+		}
+		for j, val := range e.Values {
+			if j > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(strconv.Itoa(val))
+		}
+	}
+	return sb.String()
+}
+
+//ParsePatch parses the text form written by String
+func ParsePatch(s string) (Patch, error) {
+	p := Patch{}
+	for _, field := range strings.Fields(s) {
+		op := map[byte]DiffOp{'=': DiffKeep, '-': DiffDelete, '+': DiffInsert}[field[0]]
+		if op == 0 || len(field) == 1 {
+			return nil, errors.New("Invalid patch run " + field)
+		}
+		if op == DiffKeep {
+			n, err := strconv.Atoi(field[1:])
+			if err != nil || n <= 0 {
+				return nil, errors.New("Invalid patch run " + field)
+			}
+			p = append(p, DiffEdit{Op: op, Count: n})
+			continue
+		}
+		e := DiffEdit{Op: op}
+		for _, num := range strings.Split(field[1:], ",") {
+			val, err := strconv.Atoi(num)
+			if err != nil {
+				return nil, errors.New("Invalid patch run " + field)
+			}
+			e.Values = append(e.Values, val)
+		}
+		e.Count = len(e.Values)
+		p = append(p, e)
+	}
+	return p, nil
+}
+
+//Serialized returns the binary form of the patch: every run is its op as a single byte and its count,
+//followed by the values for deletions and insertions, all numbers as 8 byte big endian words
+func (p Patch) Serialized() []byte {
+	var b []byte
+	for _, e := range p {
+		b = append(b, byte(e.Op))
+		b = appendWord(b, e.Count)
+		if e.Op != DiffKeep {
+			b = appendWords(b, e.Values)
+		}
+	}
+	return b
+}
+
+//DeserializeFrom replaces the patch with the one encoded in b by Serialized
+func (p *Patch) DeserializeFrom(b []byte) error {
+	tmp := Patch{}
+	r := wordReader{b: b}
+	for !r.done() {
+		e := DiffEdit{Op: DiffOp(r.b[0])}
+		r.b = r.b[1:]
+		var err error
+		if e.Count, err = r.word(); err != nil {
+			return err
+		}
+		switch e.Op {
+		case DiffKeep:
+			if e.Count <= 0 {
+				return errors.New("Invalid patch")
+			}
+		case DiffDelete, DiffInsert:
+			if e.Values, err = r.words(e.Count); err != nil {
+				return err
+			}
+		default:
+			return errors.New("Invalid patch")
+		}
+		tmp = append(tmp, e)
+	}
+	*p = tmp
+	return nil
+}
+
+//Conflict is a region that both sides of a Merge changed in different ways
+type Conflict struct {
+	//Idx is the position of the region in the merged vector, which holds the Base elements there
+	Idx  int
+	Base []int
+	A    []int
+	B    []int
+}
+
+//hunk is a change to the base, the elements base[lo:hi] are replaced by vals
+type hunk struct {
+	lo, hi int
+	vals   []int
+}
+
+//hunks returns the changes the patch makes to the base in order
+func (p Patch) hunks() []hunk {
+	var hs []hunk
+	i := 0
+	for _, e := range p {
+		if e.Op == DiffKeep {
+			i += e.Count
+			continue
+		}
+		//runs that follow each other without a kept element in between are a single change
+		if n := len(hs); n == 0 || hs[n-1].hi != i {
+			hs = append(hs, hunk{lo: i, hi: i})
+		}
+		h := &hs[len(hs)-1]
+		if e.Op == DiffDelete {
+			h.hi += e.Count
+			i += e.Count
+		} else {
+			h.vals = append(h.vals, e.Values...)
+		}
+	}
+	return hs
+}
+
+//Merge does a three-way merge of a and b, which were both changed from base. Changes made by only one side
+//and identical changes made by both sides are applied, regions changed differently by both sides are
+//reported as conflicts and keep the base elements in the merged vector
+func Merge(base, a, b *Intvector) (*Intvector, []Conflict) {
+	ha, hb := Diff(base, a).hunks(), Diff(base, b).hunks()
+	merged := &Intvector{}
+	var conflicts []Conflict
+
+	pos := 0
+	for len(ha) > 0 || len(hb) > 0 {
+		//start a group with the first hunk of either side and add every hunk that overlaps it
+		var ga, gb []hunk
+		var lo, hi int
+		if len(hb) == 0 || len(ha) > 0 && ha[0].lo <= hb[0].lo {
+			ga, ha = append(ga, ha[0]), ha[1:]
+			lo, hi = ga[0].lo, ga[0].hi
+		} else {
+			gb, hb = append(gb, hb[0]), hb[1:]
+			lo, hi = gb[0].lo, gb[0].hi
+		}
+		for {
+			var h hunk
+			if len(ha) > 0 && overlaps(ha[0], lo, hi) {
+				h, ga, ha = ha[0], append(ga, ha[0]), ha[1:]
+			} else if len(hb) > 0 && overlaps(hb[0], lo, hi) {
+				h, gb, hb = hb[0], append(gb, hb[0]), hb[1:]
+			} else {
+				break
+			}
+			if h.hi > hi {
+				hi = h.hi
+			}
+		}
+
+		merged.vec = append(merged.vec, base.vec[pos:lo]...)
+		pos = hi
+		va, vb := region(base.vec, lo, hi, ga), region(base.vec, lo, hi, gb)
+		switch {
+		case len(gb) == 0:
+			merged.vec = append(merged.vec, va...)
+		case len(ga) == 0, equalInts(va, vb):
+			merged.vec = append(merged.vec, vb...)
+		default:
+			conflicts = append(conflicts, Conflict{
+				Idx:  len(merged.vec),
+				Base: append([]int{}, base.vec[lo:hi]...),
+				A:    va,
+				B:    vb,
+			})
+			merged.vec = append(merged.vec, base.vec[lo:hi]...)
+		}
+	}
+	merged.vec = append(merged.vec, base.vec[pos:]...)
+	return merged, conflicts
+}
+
+//overlaps returns true if the hunk changes the base region [lo, hi) or its borders, h must not start before lo
+//Two changes at the same place overlap even if one of them only inserts, since their order would be ambiguous
+func overlaps(h hunk, lo, hi int) bool {
+	return h.lo < hi || h.lo == lo || h.lo == hi && (h.lo == h.hi || lo == hi)
+}
+
+//region returns base[lo:hi] with the hunks applied, the hunks must lie inside the region
+func region(base []int, lo, hi int, hs []hunk) []int {
+	res := []int{}
+	pos := lo
+	for _, h := range hs {
+		res = append(res, base[pos:h.lo]...)
+		res = append(res, h.vals...)
+		pos = h.hi
+	}
+	return append(res, base[pos:hi]...)
+}
+
+//equalInts returns true if both slices hold the same elements
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package intvector
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+//lcs returns the length of the longest common subsequence of a and b
+func lcs(a, b []int) int {
+	dp := make([][]int, len(a)+1)
+	for i := range dp {
+		dp[i] = make([]int, len(b)+1)
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			if a[i-1] == b[j-1] {
+				dp[i][j] = dp[i-1][j-1] + 1
+			} else if dp[i-1][j] > dp[i][j-1] {
+				dp[i][j] = dp[i-1][j]
+			} else {
+				dp[i][j] = dp[i][j-1]
+			}
+		}
+	}
+	return dp[len(a)][len(b)]
+}
+
+func TestDiff(t *testing.T) {
+	var a, b Intvector
+	a.Insert(1, 2, 3, 4, 5)
+	b.Insert(1, 3, 4, 9, 5, 6)
+	p := Diff(&a, &b)
+	if got, want := p.String(), "=1 -2 =2 +9 =1 +6"; got != want {
+		t.Errorf("Diff Test failed : want %q got %q", want, got)
+	}
+
+	for i := 0; i < 300; i++ {
+		var a, b Intvector
+		for j := rand.Intn(40); j > 0; j-- {
+			a.Push(rand.Intn(5))
+		}
+		for j := rand.Intn(40); j > 0; j-- {
+			b.Push(rand.Intn(5))
+		}
+		p := Diff(&a, &b)
+		if want := a.Size() + b.Size() - 2*lcs(a.vec, b.vec); p.Distance() != want {
+			t.Fatalf("Diff Test failed : want distance %d got %d for %v and %v", want, p.Distance(), a.vec, b.vec)
+		}
+		if p.Len() != a.Size() {
+			t.Fatalf("Diff Test failed : want len %d got %d", a.Size(), p.Len())
+		}
+		if err := a.ApplyPatch(p); err != nil || a.Hash() != b.Hash() {
+			t.Fatalf("Diff Test failed : patch does not turn a into b, %v", err)
+		}
+	}
+}
+
+func TestDiffLarge(t *testing.T) {
+	var a Intvector
+	for i := 0; i < 100000; i++ {
+		a.Push(rand.Int())
+	}
+	b := a.Clone()
+	for i := 0; i < 20; i++ {
+		b.RemoveAt(rand.Intn(b.Size()))
+		b.Set(rand.Intn(b.Size()), -1)
+	}
+	p := Diff(&a, b)
+	if p.Distance() > 60 {
+		t.Errorf("DiffLarge Test failed : want at most 60 changes got %d", p.Distance())
+	}
+	if err := a.ApplyPatch(p); err != nil || a.Hash() != b.Hash() {
+		t.Errorf("DiffLarge Test failed : patch does not turn a into b, %v", err)
+	}
+}
+
+func TestPatchForms(t *testing.T) {
+	var a, b Intvector
+	a.Insert(-5, 0, 7, 7, 1)
+	b.Insert(-5, -8, 7, 2, 3, 1, 4)
+	p := Diff(&a, &b)
+
+	parsed, err := ParsePatch(p.String())
+	if err != nil || !reflect.DeepEqual(parsed, p) {
+		t.Errorf("PatchForms Test failed : text form does not round trip %q, %v", p.String(), err)
+	}
+	var decoded Patch
+	if err := decoded.DeserializeFrom(p.Serialized()); err != nil || !reflect.DeepEqual(decoded, p) {
+		t.Errorf("PatchForms Test failed : binary form does not round trip, %v", err)
+	}
+
+	for _, s := range []string{"=0", "*1", "+1,,2", "=x", "-"} {
+		if _, err := ParsePatch(s); err == nil {
+			t.Errorf("PatchForms Test failed : want an error parsing %q", s)
+		}
+	}
+	if err := decoded.DeserializeFrom(p.Serialized()[:10]); err == nil {
+		t.Error("PatchForms Test failed : want an error for a truncated patch")
+	}
+
+	//counts near the largest int must not overflow the bounds checks
+	huge, err := ParsePatch("=1 =" + strconv.Itoa(math.MaxInt))
+	if err != nil {
+		t.Fatalf("PatchForms Test failed : ParsePatch returned error %s", err)
+	}
+	if err := a.ApplyPatch(huge); err == nil {
+		t.Error("PatchForms Test failed : want an error applying a patch with a huge count")
+	}
+
+	//a patch only applies to the vector it was made for
+	hash := b.Hash()
+	if err := b.ApplyPatch(p); err == nil || b.Hash() != hash {
+		t.Error("PatchForms Test failed : want an error applying the patch to the wrong vector")
+	}
+}
+
+func TestMerge(t *testing.T) {
+	var base, a, b Intvector
+	base.Insert(1, 2, 3, 4, 5, 6, 7, 8)
+	a.Insert(0, 1, 2, 3, 40, 5, 6, 7, 8)
+	b.Insert(1, 2, 3, 40, 5, 6, 8, 9)
+
+	merged, conflicts := Merge(&base, &a, &b)
+	want := []int{0, 1, 2, 3, 40, 5, 6, 8, 9}
+	if len(conflicts) != 0 || !reflect.DeepEqual(merged.vec, want) {
+		t.Errorf("Merge Test failed : want %v without conflicts got %v and %v", want, merged.vec, conflicts)
+	}
+
+	a.Set(4, 41)
+	merged, conflicts = Merge(&base, &a, &b)
+	wantConflict := Conflict{Idx: 4, Base: []int{4}, A: []int{41}, B: []int{40}}
+	if len(conflicts) != 1 || !reflect.DeepEqual(conflicts[0], wantConflict) {
+		t.Fatalf("Merge Test failed : want %v got %v", wantConflict, conflicts)
+	}
+	want = []int{0, 1, 2, 3, 4, 5, 6, 8, 9}
+	if !reflect.DeepEqual(merged.vec, want) {
+		t.Errorf("Merge Test failed : want %v got %v", want, merged.vec)
+	}
+
+	//both sides inserting at the same place is a conflict too
+	var c, d Intvector
+	c.Insert(1, 2, 3, 4, 5, 6, 7, 8, 10)
+	d.Insert(1, 2, 3, 4, 5, 6, 7, 8, 11)
+	if _, conflicts := Merge(&base, &c, &d); len(conflicts) != 1 {
+		t.Errorf("Merge Test failed : want 1 conflict got %v", conflicts)
+	}
+}
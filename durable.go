@@ -0,0 +1,389 @@
+package intvector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+//DurableIntvector is a vector whose mutations are appended to a checksummed write-ahead log before they return,
+//so its contents survive a crash without rewriting the whole vector after every change.
+//The log is compacted into a snapshot in the layout of Serialized every CompactEvery records.
+//
+//The directory holds two files:
+//	snapshot: "IVW1", generation, crc32 of the serialized vector, serialized vector
+//	log:      "IVL1", generation, then records of payload length, crc32 of the payload and payload
+//A log only applies to the snapshot of the same generation, which makes compaction safe to interrupt at any point
+type DurableIntvector struct {
+	dir  string
+	opts DurableOptions
+	vec  Intvector
+	log  *os.File
+	gen  uint64
+	//records is the number of records in the current log
+	records int
+}
+
+//DurableOptions configures a DurableIntvector
+type DurableOptions struct {
+	//CompactEvery is the number of log records after which the log is compacted into a snapshot, 0 disables it
+	CompactEvery int
+	//NoSync skips the fsync after every record, a crash may then lose the last mutations but never corrupts the vector
+	NoSync bool
+}
+
+const (
+	walSnapshotFile = "snapshot"
+	walLogFile      = "log"
+)
+
+var (
+	walSnapshotMagic = []byte("IVW1")
+	walLogMagic      = []byte("IVL1")
+)
+
+//walOp identifies the mutation stored in a log record
+type walOp byte
+
+const (
+	walPush walOp = iota + 1
+	walPop
+	walSet
+	walRemoveAt
+	walInsert
+	walClear
+)
+
+//OpenDurable opens the durable vector stored in dir, creating it if needed.
+//The snapshot is loaded and the log is replayed on top of it, a torn record at the end of the log is cut off
+func OpenDurable(dir string, opts DurableOptions) (*DurableIntvector, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	d := &DurableIntvector{dir: dir, opts: opts}
+
+	if err := d.loadSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := d.replayLog(); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+//loadSnapshot reads the snapshot, a missing snapshot is an empty vector of generation 0
+func (d *DurableIntvector) loadSnapshot() error {
+	b, err := os.ReadFile(filepath.Join(d.dir, walSnapshotFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	//the snapshot is only ever replaced by a rename, so any damage is real corruption and not a torn write
+	if len(b) < 16 || !bytes.Equal(b[:4], walSnapshotMagic) {
+		return errors.New("Invalid snapshot")
+	}
+	payload := b[16:]
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(b[12:]) {
+		return errors.New("Snapshot checksum mismatch")
+	}
+	if len(payload) > 0 {
+		if err := d.vec.DeserializeFrom(payload, false); err != nil {
+			return err
+		}
+	}
+	d.gen = binary.BigEndian.Uint64(b[4:])
+	return nil
+}
+
+//replayLog applies the records of the log to the vector and leaves the log open for appending
+func (d *DurableIntvector) replayLog() error {
+	f, err := os.OpenFile(filepath.Join(d.dir, walLogFile), os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	d.log = f
+
+	b, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	if len(b) < 12 || !bytes.Equal(b[:4], walLogMagic) || binary.BigEndian.Uint64(b[4:]) != d.gen {
+		//a new log, a log torn while it was being created or a log that is already part of the snapshot
+		return d.resetLog()
+	}
+
+	offset := 12
+	for offset < len(b) {
+		rec := b[offset:]
+		if len(rec) < 8 {
+			break
+		}
+		size := binary.BigEndian.Uint32(rec)
+		if uint64(size) > uint64(len(rec)-8) {
+			break
+		}
+		payload := rec[8 : 8+size]
+		if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(rec[4:]) {
+			break
+		}
+		//a zero filled tail has a matching checksum, as the crc32 of an empty payload is 0, so a record
+		//that does not decode is treated as torn as well
+		op, args, err := decodeRecord(payload)
+		if err == errInvalidRecord {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := d.apply(op, args); err != nil {
+			return err
+		}
+		offset += 8 + int(size)
+		d.records++
+	}
+
+	//cut off the torn tail so that new records follow the last complete one
+	if offset < len(b) {
+		if err := f.Truncate(int64(offset)); err != nil {
+			return err
+		}
+	}
+	_, err = f.Seek(int64(offset), io.SeekStart)
+	return err
+}
+
+//resetLog replaces the log with an empty one for the current generation
+func (d *DurableIntvector) resetLog() error {
+	header := append(append([]byte{}, walLogMagic...), make([]byte, 8)...)
+	binary.BigEndian.PutUint64(header[4:], d.gen)
+	if err := writeFileAtomic(filepath.Join(d.dir, walLogFile), header); err != nil {
+		return err
+	}
+
+	if d.log != nil {
+		d.log.Close()
+	}
+	f, err := os.OpenFile(filepath.Join(d.dir, walLogFile), os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return err
+	}
+	d.log = f
+	d.records = 0
+	return nil
+}
+
+//errInvalidRecord is returned by decodeRecord for a payload that was never written by record
+var errInvalidRecord = errors.New("Invalid log record")
+
+//decodeRecord splits a record payload into its operation and arguments
+func decodeRecord(payload []byte) (walOp, []int, error) {
+	if len(payload) == 0 || (len(payload)-1)%8 != 0 {
+		return 0, nil, errInvalidRecord
+	}
+	op := walOp(payload[0])
+	//the number of arguments of every operation, -1 for any number
+	want := map[walOp]int{walPush: 1, walPop: 0, walSet: 2, walRemoveAt: 1, walInsert: -1, walClear: 0}
+	n, ok := want[op]
+	if !ok || n >= 0 && n != (len(payload)-1)/8 {
+		return 0, nil, errInvalidRecord
+	}
+	args, err := decodeWords(nil, payload[1:])
+	if err != nil {
+		return 0, nil, err
+	}
+	return op, args, nil
+}
+
+//apply applies a decoded record to the in memory vector
+func (d *DurableIntvector) apply(op walOp, args []int) error {
+	var err error
+	switch op {
+	case walPush:
+		d.vec.Push(args[0])
+	case walPop:
+		_, err = d.vec.Pop()
+	case walSet:
+		err = d.vec.Set(args[0], args[1])
+	case walRemoveAt:
+		err = d.vec.RemoveAt(args[0])
+	case walInsert:
+		d.vec.Insert(args...)
+	case walClear:
+		d.vec.Clear()
+	}
+	return err
+}
+
+//record writes a mutation to the log, applies it and compacts the log if it is due
+//The callers validate the mutation first so that a record that can not be applied never reaches the log
+func (d *DurableIntvector) record(op walOp, args ...int) error {
+	payload := appendWords([]byte{byte(op)}, args)
+	rec := make([]byte, 8, 8+len(payload))
+	binary.BigEndian.PutUint32(rec, uint32(len(payload)))
+	binary.BigEndian.PutUint32(rec[4:], crc32.ChecksumIEEE(payload))
+	rec = append(rec, payload...)
+
+	offset, err := d.log.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err := d.log.Write(rec); err != nil {
+		//do not leave a partial record in front of the next one
+		d.log.Truncate(offset)
+		d.log.Seek(offset, io.SeekStart)
+		return err
+	}
+	if !d.opts.NoSync {
+		if err := d.log.Sync(); err != nil {
+			return err
+		}
+	}
+	d.records++
+	if err := d.apply(op, args); err != nil {
+		return err
+	}
+
+	if d.opts.CompactEvery > 0 && d.records >= d.opts.CompactEvery {
+		return d.Compact()
+	}
+	return nil
+}
+
+//Compact writes the vector into a new snapshot and starts a new, empty log
+func (d *DurableIntvector) Compact() error {
+	payload := d.vec.Serialized()
+	b := make([]byte, 16, 16+len(payload))
+	copy(b, walSnapshotMagic)
+	binary.BigEndian.PutUint64(b[4:], d.gen+1)
+	binary.BigEndian.PutUint32(b[12:], crc32.ChecksumIEEE(payload))
+	b = append(b, payload...)
+
+	//once the snapshot is renamed in place the old log no longer matches its generation and is ignored
+	if err := writeFileAtomic(filepath.Join(d.dir, walSnapshotFile), b); err != nil {
+		return err
+	}
+	d.gen++
+	return d.resetLog()
+}
+
+//tmpSuffix follows the name of the target in the names of the temporary files of writeFileAtomic
+const tmpSuffix = ".tmp"
+
+//writeFileAtomic replaces the file at path with b so that a crash leaves either the old or the new file
+//The temporary file has a unique name, so concurrent writers of the same path do not collide
+func writeFileAtomic(path string, b []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+tmpSuffix+"*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	err = f.Chmod(0644)
+	if err == nil {
+		_, err = f.Write(b)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	defer dir.Close()
+	return dir.Sync()
+}
+
+//Push inserts/pushes a new integer at the back of the vector
+func (d *DurableIntvector) Push(s int) error {
+	return d.record(walPush, s)
+}
+
+//Insert appends the given integers to the back of the vector
+func (d *DurableIntvector) Insert(s ...int) error {
+	if len(s) == 0 {
+		return nil
+	}
+	return d.record(walInsert, s...)
+}
+
+//Pop removes the last element from the vector and returns it
+func (d *DurableIntvector) Pop() (int, error) {
+	s, err := d.vec.Last()
+	if err != nil {
+		return 0, err
+	}
+	return s, d.record(walPop)
+}
+
+//Set function can be used to set the value at a specific index in the vector
+func (d *DurableIntvector) Set(idx int, value int) error {
+	if _, err := d.vec.At(idx); err != nil {
+		return err
+	}
+	return d.record(walSet, idx, value)
+}
+
+//RemoveAt removes the element at the given idx
+func (d *DurableIntvector) RemoveAt(idx int) error {
+	if _, err := d.vec.At(idx); err != nil {
+		return err
+	}
+	return d.record(walRemoveAt, idx)
+}
+
+//Clear clears out the vector
+func (d *DurableIntvector) Clear() error {
+	return d.record(walClear)
+}
+
+//Size returns the current size of the vector
+func (d *DurableIntvector) Size() int {
+	return d.vec.Size()
+}
+
+//At allows for accesing any element of the vector
+func (d *DurableIntvector) At(i int) (int, error) {
+	return d.vec.At(i)
+}
+
+//Hash returns the sha256 hash of the serialized version of the vector
+func (d *DurableIntvector) Hash() string {
+	return d.vec.Hash()
+}
+
+//Intvector returns a copy of the vector for the read methods of Intvector
+func (d *DurableIntvector) Intvector() *Intvector {
+	var v Intvector
+	v.Insert(d.vec.vec...)
+	return &v
+}
+
+//Close closes the log, the vector must not be used afterwards
+func (d *DurableIntvector) Close() error {
+	if err := d.log.Sync(); err != nil {
+		d.log.Close()
+		return err
+	}
+	return d.log.Close()
+}
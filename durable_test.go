@@ -0,0 +1,239 @@
+package intvector
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//durableOps applies a fixed series of mutations and returns the hash of the vector after each of them
+func durableOps(t *testing.T, d *DurableIntvector) []string {
+	hashes := []string{d.Hash()}
+	step := func(err error) {
+		if err != nil {
+			t.Fatalf("Durable Test failed : mutation returned error %s", err)
+		}
+		hashes = append(hashes, d.Hash())
+	}
+
+	step(d.Push(1))
+	step(d.Push(2))
+	step(d.Insert(3, 4, 5))
+	step(d.Set(0, 10))
+	_, err := d.Pop()
+	step(err)
+	step(d.RemoveAt(1))
+	step(d.Clear())
+	step(d.Insert(-7, 8))
+	step(d.Push(9))
+	return hashes
+}
+
+//copyDir copies the files of src into a new temporary directory
+func copyDir(t *testing.T, src string) string {
+	dst := t.TempDir()
+	entries, _ := os.ReadDir(src)
+	for _, e := range entries {
+		b, _ := os.ReadFile(filepath.Join(src, e.Name()))
+		os.WriteFile(filepath.Join(dst, e.Name()), b, 0644)
+	}
+	return dst
+}
+
+func TestDurableIntvector(t *testing.T) {
+	dir := t.TempDir()
+	d, err := OpenDurable(dir, DurableOptions{})
+	if err != nil {
+		t.Fatalf("Durable Test failed : OpenDurable returned error %s", err)
+	}
+	hashes := durableOps(t, d)
+	want := d.Hash()
+	d.Close()
+
+	d, err = OpenDurable(dir, DurableOptions{})
+	if err != nil {
+		t.Fatalf("Durable Test failed : reopening returned error %s", err)
+	}
+	defer d.Close()
+	if d.Hash() != want || d.Hash() != hashes[len(hashes)-1] {
+		t.Error("Durable Test failed : reopened vector differs")
+	}
+	if got, _ := d.At(0); got != -7 {
+		t.Errorf("Durable Test failed : want %d at index 0 got %d", -7, got)
+	}
+
+	//invalid mutations must fail without reaching the log
+	if err := d.Set(10, 1); err == nil {
+		t.Error("Durable Test failed : Set should return error for out of range index")
+	}
+	if err := d.RemoveAt(-1); err == nil {
+		t.Error("Durable Test failed : RemoveAt should return error for out of range index")
+	}
+	if d.records != len(hashes)-1 {
+		t.Errorf("Durable Test failed : want %d log records got %d", len(hashes)-1, d.records)
+	}
+}
+
+func TestDurableCompact(t *testing.T) {
+	dir := t.TempDir()
+	d, _ := OpenDurable(dir, DurableOptions{CompactEvery: 3})
+	hashes := durableOps(t, d)
+	d.Close()
+
+	if d.gen != 3 {
+		t.Errorf("Durable Compact Test failed : want generation 3 got %d", d.gen)
+	}
+
+	d, err := OpenDurable(dir, DurableOptions{CompactEvery: 3})
+	if err != nil {
+		t.Fatalf("Durable Compact Test failed : reopening returned error %s", err)
+	}
+	defer d.Close()
+	if d.Hash() != hashes[len(hashes)-1] {
+		t.Error("Durable Compact Test failed : reopened vector differs")
+	}
+}
+
+//TestDurableTornLog simulates a crash in the middle of writing a record by cutting the log at every possible length
+func TestDurableTornLog(t *testing.T) {
+	dir := t.TempDir()
+	d, _ := OpenDurable(dir, DurableOptions{})
+	hashes := durableOps(t, d)
+	d.Close()
+
+	logPath := filepath.Join(dir, walLogFile)
+	full, _ := os.ReadFile(logPath)
+
+	//record the length of the log after each record so the expected state of a cut can be found
+	var ends []int
+	for offset := 12; offset < len(full); {
+		size := int(full[offset])<<24 | int(full[offset+1])<<16 | int(full[offset+2])<<8 | int(full[offset+3])
+		offset += 8 + size
+		ends = append(ends, offset)
+	}
+
+	for cut := 12; cut <= len(full); cut++ {
+		crashed := copyDir(t, dir)
+		os.WriteFile(filepath.Join(crashed, walLogFile), full[:cut], 0644)
+
+		complete := 0
+		for complete < len(ends) && ends[complete] <= cut {
+			complete++
+		}
+
+		r, err := OpenDurable(crashed, DurableOptions{})
+		if err != nil {
+			t.Errorf("Durable Torn Log Test failed : recovery at length %d returned error %s", cut, err)
+			continue
+		}
+		if r.Hash() != hashes[complete] {
+			t.Errorf("Durable Torn Log Test failed : at length %d want the state after %d records", cut, complete)
+		}
+
+		//new records must follow the last complete one and survive another restart
+		r.Push(100)
+		want := r.Hash()
+		r.Close()
+		r, err = OpenDurable(crashed, DurableOptions{})
+		if err != nil || r.Hash() != want {
+			t.Errorf("Durable Torn Log Test failed : record written after recovery at length %d was lost (err %v)", cut, err)
+		}
+		if r != nil {
+			r.Close()
+		}
+	}
+}
+
+func TestDurableCorruptRecord(t *testing.T) {
+	dir := t.TempDir()
+	d, _ := OpenDurable(dir, DurableOptions{})
+	hashes := durableOps(t, d)
+	d.Close()
+
+	//a flipped bit in the last record fails its checksum, so only the records before it are recovered
+	logPath := filepath.Join(dir, walLogFile)
+	b, _ := os.ReadFile(logPath)
+	b[len(b)-1] ^= 1
+	os.WriteFile(logPath, b, 0644)
+
+	r, err := OpenDurable(dir, DurableOptions{})
+	if err != nil {
+		t.Fatalf("Durable Corrupt Record Test failed : recovery returned error %s", err)
+	}
+	defer r.Close()
+	if r.Hash() != hashes[len(hashes)-2] {
+		t.Error("Durable Corrupt Record Test failed : want the state before the corrupted record")
+	}
+}
+
+//TestDurableZeroTail simulates a crash after the file system extended the log but before the record reached it
+func TestDurableZeroTail(t *testing.T) {
+	dir := t.TempDir()
+	d, _ := OpenDurable(dir, DurableOptions{})
+	hashes := durableOps(t, d)
+	d.Close()
+
+	logPath := filepath.Join(dir, walLogFile)
+	b, _ := os.ReadFile(logPath)
+	os.WriteFile(logPath, append(b, make([]byte, 4096)...), 0644)
+
+	r, err := OpenDurable(dir, DurableOptions{})
+	if err != nil {
+		t.Fatalf("Durable Zero Tail Test failed : recovery returned error %s", err)
+	}
+	if r.Hash() != hashes[len(hashes)-1] {
+		t.Error("Durable Zero Tail Test failed : want the state after every record")
+	}
+	r.Push(100)
+	want := r.Hash()
+	r.Close()
+
+	//the tail was cut off, so the record written after recovery is found after another restart
+	r, err = OpenDurable(dir, DurableOptions{})
+	if err != nil || r.Hash() != want {
+		t.Fatalf("Durable Zero Tail Test failed : record written after recovery was lost (err %v)", err)
+	}
+	r.Close()
+
+	//a record with a valid checksum and an unknown operation is treated as torn as well
+	b, _ = os.ReadFile(logPath)
+	payload := []byte{255}
+	rec := binary.BigEndian.AppendUint32(nil, uint32(len(payload)))
+	rec = binary.BigEndian.AppendUint32(rec, crc32.ChecksumIEEE(payload))
+	os.WriteFile(logPath, append(b, append(rec, payload...)...), 0644)
+	r, err = OpenDurable(dir, DurableOptions{})
+	if err != nil || r.Hash() != want {
+		t.Errorf("Durable Zero Tail Test failed : want the unknown record ignored got error %v", err)
+	}
+	if r != nil {
+		r.Close()
+	}
+}
+
+//TestDurableInterruptedCompact simulates a crash after the new snapshot is in place but before the log was reset
+func TestDurableInterruptedCompact(t *testing.T) {
+	dir := t.TempDir()
+	d, _ := OpenDurable(dir, DurableOptions{})
+	hashes := durableOps(t, d)
+	oldLog, _ := os.ReadFile(filepath.Join(dir, walLogFile))
+	if err := d.Compact(); err != nil {
+		t.Fatalf("Durable Interrupted Compact Test failed : Compact returned error %s", err)
+	}
+	d.Close()
+
+	//put the old log back, its records are already part of the snapshot and must not be applied twice
+	os.WriteFile(filepath.Join(dir, walLogFile), oldLog, 0644)
+	//a leftover temporary file must be ignored as well
+	os.WriteFile(filepath.Join(dir, walSnapshotFile+".tmp"), []byte("garbage"), 0644)
+
+	r, err := OpenDurable(dir, DurableOptions{})
+	if err != nil {
+		t.Fatalf("Durable Interrupted Compact Test failed : recovery returned error %s", err)
+	}
+	defer r.Close()
+	if r.Hash() != hashes[len(hashes)-1] {
+		t.Error("Durable Interrupted Compact Test failed : old log was replayed on top of the new snapshot")
+	}
+}
@@ -0,0 +1,348 @@
+package intvector
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+//A minimal flatbuffers encoder and decoder, enough for the metadata of the Arrow IPC format without
+//depending on the flatbuffers package or on generated code.
+//
+//A flatbuffer starts with the offset of its root table. A table starts with the signed distance back to its
+//vtable, followed by its inline fields. The vtable has its own size, the inline size of the table and then
+//for every field id the position of the field in the table, 0 for a field that is absent and has its default.
+//Offsets to tables, vectors and strings are unsigned distances from the field to the object, so objects are
+//always written after the fields that refer to them. Vectors and strings start with their 32 bit length,
+//strings end with a NUL byte. Everything is little endian and aligned to its size.
+
+//errFlatBuffer is returned for metadata that is not a valid flatbuffer
+var errFlatBuffer = errors.New("Invalid flatbuffer")
+
+//fbTableOut is a table to be encoded, the slots are indexed by field id
+type fbTableOut []fbSlot
+
+//fbSlot is a field of a table to be encoded, either a scalar or an offset to another object
+type fbSlot struct {
+	//size is the size of the scalar in bytes, or 4 for an offset. A zero slot is an absent field
+	size int
+	bits uint64
+	ref  interface{}
+}
+
+//fbTables is a vector of tables to be encoded
+type fbTables []fbTableOut
+
+//fbStructs is a vector of structs to be encoded, data has all the structs one after the other
+type fbStructs struct {
+	n     int
+	align int
+	data  []byte
+}
+
+//fbScalar returns a slot with a scalar of the given size in bytes
+func fbScalar(size int, bits uint64) fbSlot {
+	return fbSlot{size: size, bits: bits}
+}
+
+//fbRef returns a slot with an offset to an fbTableOut, fbTables, fbStructs or string
+func fbRef(obj interface{}) fbSlot {
+	return fbSlot{size: 4, ref: obj}
+}
+
+//fbWriter encodes a flatbuffer front to back
+type fbWriter struct {
+	b []byte
+}
+
+//buildFlatBuffer encodes a flatbuffer with the table as its root, the length is padded to a multiple of 8
+func buildFlatBuffer(root fbTableOut) []byte {
+	w := &fbWriter{b: make([]byte, 4, 256)}
+	w.ref(0, root)
+	w.pad(8)
+	return w.b
+}
+
+func (w *fbWriter) pad(align int) {
+	for len(w.b)%align != 0 {
+		w.b = append(w.b, 0)
+	}
+}
+
+func (w *fbWriter) uint16(u uint16) {
+	w.b = binary.LittleEndian.AppendUint16(w.b, u)
+}
+
+func (w *fbWriter) uint32(u uint32) {
+	w.b = binary.LittleEndian.AppendUint32(w.b, u)
+}
+
+//ref writes the object and stores the offset to it at the position at
+func (w *fbWriter) ref(at int, obj interface{}) {
+	pos := w.object(obj)
+	binary.LittleEndian.PutUint32(w.b[at:], uint32(pos-at))
+}
+
+//object writes the object and returns its position
+func (w *fbWriter) object(obj interface{}) int {
+	switch o := obj.(type) {
+	case string:
+		w.pad(4)
+		pos := len(w.b)
+		w.uint32(uint32(len(o)))
+		w.b = append(w.b, o...)
+		w.b = append(w.b, 0)
+		return pos
+
+	case fbStructs:
+		//the structs after the length need the alignment of the struct
+		for (len(w.b)+4)%o.align != 0 {
+			w.b = append(w.b, 0)
+		}
+		pos := len(w.b)
+		w.uint32(uint32(o.n))
+		w.b = append(w.b, o.data...)
+		return pos
+
+	case fbTables:
+		w.pad(4)
+		pos := len(w.b)
+		w.uint32(uint32(len(o)))
+		w.b = append(w.b, make([]byte, 4*len(o))...)
+		for i, t := range o {
+			w.ref(pos+4+4*i, t)
+		}
+		return pos
+
+	case fbTableOut:
+		return w.table(o)
+	}
+	panic("intvector: unknown flatbuffer object")
+}
+
+//table writes the vtable and the table and then the objects the table refers to, it returns the position of the table
+func (w *fbWriter) table(t fbTableOut) int {
+	//the inline fields follow the offset to the vtable, largest first so that each is aligned to its size
+	offs := make([]int, len(t))
+	size, align := 4, 4
+	for _, sz := range []int{8, 4, 2, 1} {
+		for i, s := range t {
+			if s.size != sz {
+				continue
+			}
+			size = (size + sz - 1) / sz * sz
+			offs[i] = size
+			size += sz
+			if sz > align {
+				align = sz
+			}
+		}
+	}
+
+	w.pad(2)
+	vt := len(w.b)
+	w.uint16(uint16(4 + 2*len(t)))
+	w.uint16(uint16(size))
+	for _, off := range offs {
+		w.uint16(uint16(off))
+	}
+
+	w.pad(align)
+	pos := len(w.b)
+	w.b = append(w.b, make([]byte, size)...)
+	binary.LittleEndian.PutUint32(w.b[pos:], uint32(int32(pos-vt)))
+	for i, s := range t {
+		field := w.b[pos+offs[i]:]
+		switch {
+		case s.ref != nil:
+		case s.size == 1:
+			field[0] = byte(s.bits)
+		case s.size == 2:
+			binary.LittleEndian.PutUint16(field, uint16(s.bits))
+		case s.size == 4:
+			binary.LittleEndian.PutUint32(field, uint32(s.bits))
+		case s.size == 8:
+			binary.LittleEndian.PutUint64(field, s.bits)
+		}
+	}
+	for i, s := range t {
+		if s.ref != nil {
+			w.ref(pos+offs[i], s.ref)
+		}
+	}
+	return pos
+}
+
+//flatBuffer is a flatbuffer being decoded. The first read out of bounds sets err, after which every read
+//returns zero values, so a decoder can check err once at the end instead of after every read
+type flatBuffer struct {
+	b   []byte
+	err error
+}
+
+//fbTable is a table of a flatBuffer, the zero fbTable is an absent table whose fields all have their defaults
+type fbTable struct {
+	fb  *flatBuffer
+	pos int
+}
+
+//fail sets err unless it is already set
+func (fb *flatBuffer) fail() {
+	if fb.err == nil {
+		fb.err = errFlatBuffer
+	}
+}
+
+//check returns true if n bytes at pos are within the buffer, and sets err otherwise
+func (fb *flatBuffer) check(pos int, n int) bool {
+	if fb.err != nil {
+		return false
+	}
+	if pos < 0 || n < 0 || pos > len(fb.b)-n {
+		fb.fail()
+		return false
+	}
+	return true
+}
+
+func (fb *flatBuffer) uint16(pos int) uint16 {
+	if !fb.check(pos, 2) {
+		return 0
+	}
+	return binary.LittleEndian.Uint16(fb.b[pos:])
+}
+
+func (fb *flatBuffer) uint32(pos int) uint32 {
+	if !fb.check(pos, 4) {
+		return 0
+	}
+	return binary.LittleEndian.Uint32(fb.b[pos:])
+}
+
+func (fb *flatBuffer) uint64(pos int) uint64 {
+	if !fb.check(pos, 8) {
+		return 0
+	}
+	return binary.LittleEndian.Uint64(fb.b[pos:])
+}
+
+//deref returns the position of the object whose offset is stored at pos, or -1 if it is out of bounds
+func (fb *flatBuffer) deref(pos int) int {
+	off := int64(fb.uint32(pos))
+	if fb.err != nil || int64(pos)+off >= int64(len(fb.b)) {
+		fb.fail()
+		return -1
+	}
+	return pos + int(off)
+}
+
+//root returns the root table
+func (fb *flatBuffer) root() fbTable {
+	return fb.tableAt(fb.deref(0))
+}
+
+//tableAt returns the table at pos after checking its vtable
+func (fb *flatBuffer) tableAt(pos int) fbTable {
+	vt := int64(pos) - int64(int32(fb.uint32(pos)))
+	if fb.err != nil || vt < 0 || vt > int64(len(fb.b)) {
+		fb.fail()
+		return fbTable{}
+	}
+	size := int(fb.uint16(int(vt)))
+	if size < 4 || size%2 != 0 || !fb.check(int(vt), size) {
+		fb.fail()
+		return fbTable{}
+	}
+	return fbTable{fb: fb, pos: pos}
+}
+
+//field returns the position of the field, or 0 if it is absent
+func (t fbTable) field(id int) int {
+	if t.fb == nil || t.fb.err != nil {
+		return 0
+	}
+	vt := t.pos - int(int32(t.fb.uint32(t.pos)))
+	if 4+2*id+2 > int(t.fb.uint16(vt)) {
+		return 0
+	}
+	off := int(t.fb.uint16(vt + 4 + 2*id))
+	if off == 0 {
+		return 0
+	}
+	return t.pos + off
+}
+
+func (t fbTable) uint8(id int, def uint8) uint8 {
+	pos := t.field(id)
+	if pos == 0 || !t.fb.check(pos, 1) {
+		return def
+	}
+	return t.fb.b[pos]
+}
+
+func (t fbTable) bool(id int, def bool) bool {
+	d := uint8(0)
+	if def {
+		d = 1
+	}
+	return t.uint8(id, d) != 0
+}
+
+func (t fbTable) int16(id int, def int16) int16 {
+	if pos := t.field(id); pos != 0 {
+		return int16(t.fb.uint16(pos))
+	}
+	return def
+}
+
+func (t fbTable) int32(id int, def int32) int32 {
+	if pos := t.field(id); pos != 0 {
+		return int32(t.fb.uint32(pos))
+	}
+	return def
+}
+
+func (t fbTable) int64(id int, def int64) int64 {
+	if pos := t.field(id); pos != 0 {
+		return int64(t.fb.uint64(pos))
+	}
+	return def
+}
+
+//table returns the table the field refers to, the zero fbTable if the field is absent
+func (t fbTable) table(id int) fbTable {
+	pos := t.field(id)
+	if pos == 0 {
+		return fbTable{}
+	}
+	return t.fb.tableAt(t.fb.deref(pos))
+}
+
+//vector returns the position of the first element and the length of the vector the field refers to,
+//after checking that the elements of the given size are within the buffer. An absent vector is empty
+func (t fbTable) vector(id int, size int) (int, int) {
+	pos := t.field(id)
+	if pos == 0 {
+		return 0, 0
+	}
+	pos = t.fb.deref(pos)
+	n := int64(t.fb.uint32(pos))
+	if t.fb.err != nil || n*int64(size) > int64(len(t.fb.b)) || !t.fb.check(pos+4, int(n)*size) {
+		t.fb.fail()
+		return 0, 0
+	}
+	return pos + 4, int(n)
+}
+
+//elem returns the table at index i of a vector of tables whose first element is at pos
+func (t fbTable) elem(pos int, i int) fbTable {
+	return t.fb.tableAt(t.fb.deref(pos + 4*i))
+}
+
+//string returns the string the field refers to, "" if it is absent
+func (t fbTable) string(id int) string {
+	pos, n := t.vector(id, 1)
+	if n == 0 {
+		return ""
+	}
+	return string(t.fb.b[pos : pos+n])
+}
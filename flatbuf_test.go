@@ -0,0 +1,81 @@
+package intvector
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestFlatBuffer(t *testing.T) {
+	inner := fbTableOut{fbScalar(4, 42)}
+	structs := make([]byte, 0, 32)
+	structs = binary.LittleEndian.AppendUint64(structs, 7)
+	structs = binary.LittleEndian.AppendUint64(structs, 8)
+	b := buildFlatBuffer(fbTableOut{
+		fbScalar(1, 1),
+		fbScalar(2, 0xFFFE),
+		{},
+		fbScalar(8, 1<<40),
+		fbRef("name"),
+		fbRef(inner),
+		fbRef(fbTables{inner, {}}),
+		fbRef(fbStructs{n: 2, align: 8, data: structs}),
+	})
+	if len(b)%8 != 0 {
+		t.Errorf("FlatBuffer Test failed : want a multiple of 8 got %d bytes", len(b))
+	}
+
+	fb := &flatBuffer{b: b}
+	root := fb.root()
+	if got := root.bool(0, false); !got {
+		t.Error("FlatBuffer Test failed : want true")
+	}
+	if got := root.int16(1, 0); got != -2 {
+		t.Errorf("FlatBuffer Test failed : want -2 got %d", got)
+	}
+	if got := root.int32(2, 5); got != 5 {
+		t.Errorf("FlatBuffer Test failed : want the default 5 for an absent field got %d", got)
+	}
+	if pos := root.field(3); pos%8 != 0 {
+		t.Errorf("FlatBuffer Test failed : want the int64 aligned got position %d", pos)
+	}
+	if got := root.int64(3, 0); got != 1<<40 {
+		t.Errorf("FlatBuffer Test failed : want %d got %d", int64(1<<40), got)
+	}
+	if got := root.string(4); got != "name" {
+		t.Errorf("FlatBuffer Test failed : want name got %q", got)
+	}
+	if got := root.table(5).int32(0, 0); got != 42 {
+		t.Errorf("FlatBuffer Test failed : want 42 got %d", got)
+	}
+	pos, n := root.vector(6, 4)
+	if n != 2 || root.elem(pos, 0).int32(0, 0) != 42 || root.elem(pos, 1).int32(0, 9) != 9 {
+		t.Errorf("FlatBuffer Test failed : want 2 tables got %d", n)
+	}
+	pos, n = root.vector(7, 8)
+	if n != 2 || pos%8 != 0 || binary.LittleEndian.Uint64(b[pos+8:]) != 8 {
+		t.Errorf("FlatBuffer Test failed : want 2 aligned structs got %d at %d", n, pos)
+	}
+	//ids beyond the vtable are absent
+	if root.field(100) != 0 || root.table(100).fb != nil || root.string(100) != "" {
+		t.Error("FlatBuffer Test failed : want fields beyond the vtable absent")
+	}
+	if fb.err != nil {
+		t.Errorf("FlatBuffer Test failed : %v", fb.err)
+	}
+
+	//every truncation fails instead of reading out of bounds
+	for i := 0; i < len(b); i++ {
+		fb := &flatBuffer{b: b[:i]}
+		root := fb.root()
+		root.string(4)
+		root.table(5).int32(0, 0)
+		pos, n := root.vector(6, 4)
+		for j := 0; j < n; j++ {
+			root.elem(pos, j).int32(0, 0)
+		}
+		root.vector(7, 8)
+		if fb.err == nil && i < len(b)-8 {
+			t.Errorf("FlatBuffer Test failed : want an error for %d of %d bytes", i, len(b))
+		}
+	}
+}
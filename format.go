@@ -0,0 +1,118 @@
+package intvector
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+)
+
+//logPreview is the number of elements at the head and at the tail of the vector that LogValue logs
+const logPreview = 5
+
+//logHashPrefix is the number of hex digits of the hash that LogValue logs
+const logHashPrefix = 12
+
+//String returns the elements in the form of a slice printed by fmt, [1 2 3].
+//String, Format and LogValue have value receivers so that a vector held by value prints the same
+func (v Intvector) String() string {
+	return string(appendElements(nil, v.vec, len(v.vec), 10))
+}
+
+//Format implements fmt.Formatter. The verbs v, s and d print the elements in decimal and x and X in
+//hexadecimal, like fmt prints a slice of int, so %v is [1 2 3] and %x is [1 ff -a].
+//The precision is the largest number of elements printed, %.2v is [1 2 ...(1 more)], which keeps large
+//vectors out of logs. The flags and the width apply to every element, %04x is [0001 00ff]
+func (v Intvector) Format(f fmt.State, verb rune) {
+	elem := verb
+	switch verb {
+	case 'v', 'd':
+	case 's':
+		elem = 'd'
+	case 'x', 'X', 'o', 'O', 'b':
+	default:
+		fmt.Fprintf(f, "%%!%c(intvector.Intvector=%s)", verb, v.String())
+		return
+	}
+
+	n := len(v.vec)
+	if p, ok := f.Precision(); ok && p < n {
+		n = p
+	}
+
+	//the common verbs without flags are appended directly, which is much faster for large vectors
+	_, width := f.Width()
+	plain := !width && !f.Flag('+') && !f.Flag('-') && !f.Flag('#') && !f.Flag(' ') && !f.Flag('0')
+	if plain && (elem == 'v' || elem == 'd' || elem == 'x') {
+		base := 10
+		if elem == 'x' {
+			base = 16
+		}
+		f.Write(appendElements(nil, v.vec, n, base))
+		return
+	}
+
+	spec := "%"
+	for _, flag := range "+-# 0" {
+		if f.Flag(int(flag)) {
+			spec += string(flag)
+		}
+	}
+	if w, ok := f.Width(); ok {
+		spec += strconv.Itoa(w)
+	}
+	spec += string(elem)
+
+	f.Write([]byte{'['})
+	for i, e := range v.vec[:n] {
+		if i > 0 {
+			f.Write([]byte{' '})
+		}
+		fmt.Fprintf(f, spec, e)
+	}
+	f.Write(truncated(nil, n, len(v.vec)))
+}
+
+//appendElements appends the first n elements of s to b in the given base, in brackets and separated by spaces
+func appendElements(b []byte, s []int, n int, base int) []byte {
+	b = append(b, '[')
+	for i, e := range s[:n] {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		b = strconv.AppendInt(b, int64(e), base)
+	}
+	return truncated(b, n, len(s))
+}
+
+//truncated appends the end of a list of n of size elements, which mentions the elements that were left out
+func truncated(b []byte, n int, size int) []byte {
+	if n < size {
+		if n > 0 {
+			b = append(b, ' ')
+		}
+		b = append(b, "...("...)
+		b = strconv.AppendInt(b, int64(size-n), 10)
+		b = append(b, " more)"...)
+	}
+	return append(b, ']')
+}
+
+//LogValue implements slog.LogValuer, a vector is logged as a group of its size, a prefix of its TrackedHash and its
+//elements. Only the first and the last few elements are logged as head and tail, so the log stays small
+//however large the vector is.
+//A vector that tracks its hash with TrackHash is logged in O(1) as long as it is only appended to, otherwise
+//the whole vector is serialized and hashed on every call, which is O(n)
+func (v Intvector) LogValue() slog.Value {
+	attrs := []slog.Attr{
+		slog.Int("size", len(v.vec)),
+		slog.String("hash", v.TrackedHash()[:logHashPrefix]),
+	}
+	if len(v.vec) <= 2*logPreview {
+		attrs = append(attrs, slog.Any("values", append([]int{}, v.vec...)))
+	} else {
+		attrs = append(attrs,
+			slog.Any("head", append([]int{}, v.vec[:logPreview]...)),
+			slog.Any("tail", append([]int{}, v.vec[len(v.vec)-logPreview:]...)))
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -0,0 +1,101 @@
+package intvector
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	v := Intvector{}
+	if got := v.String(); got != "[]" {
+		t.Errorf("String Test failed : want [] got %s", got)
+	}
+	v.Insert(1, -2, 3)
+	if got := v.String(); got != "[1 -2 3]" {
+		t.Errorf("String Test failed : want [1 -2 3] got %s", got)
+	}
+	//a vector prints the same by value and by pointer
+	if got := fmt.Sprint(v, &v); got != "[1 -2 3] [1 -2 3]" {
+		t.Errorf("String Test failed : want the elements twice got %s", got)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	v := Intvector{}
+	v.Insert(1, 255, -10, 4)
+	cases := []struct {
+		format string
+		want   string
+	}{
+		{"%v", "[1 255 -10 4]"},
+		{"%s", "[1 255 -10 4]"},
+		{"%d", "[1 255 -10 4]"},
+		{"%x", "[1 ff -a 4]"},
+		{"%X", "[1 FF -A 4]"},
+		{"%#x", "[0x1 0xff -0xa 0x4]"},
+		{"%04x", "[0001 00ff -00a 0004]"},
+		{"%+d", "[+1 +255 -10 +4]"},
+		{"%3v", "[  1 255 -10   4]"},
+		{"%.2v", "[1 255 ...(2 more)]"},
+		{"%.2x", "[1 ff ...(2 more)]"},
+		{"%+.1d", "[+1 ...(3 more)]"},
+		{"%.0v", "[...(4 more)]"},
+		{"%.10v", "[1 255 -10 4]"},
+		{"%q", "%!q(intvector.Intvector=[1 255 -10 4])"},
+	}
+	for _, c := range cases {
+		if got := fmt.Sprintf(c.format, v); got != c.want {
+			t.Errorf("Format Test failed : %s want %s got %s", c.format, c.want, got)
+		}
+	}
+
+	var nilv *Intvector
+	if got := fmt.Sprintf("%v", nilv); got != "<nil>" {
+		t.Errorf("Format Test failed : want <nil> got %s", got)
+	}
+}
+
+func TestLogValue(t *testing.T) {
+	var b bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&b, &slog.HandlerOptions{ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+		if a.Key == slog.TimeKey {
+			return slog.Attr{}
+		}
+		return a
+	}}))
+
+	v := Intvector{}
+	v.Insert(1, 2, 3)
+	logger.Info("small", "v", v)
+	want := fmt.Sprintf("level=INFO msg=small v.size=3 v.hash=%s v.values=\"[1 2 3]\"\n", v.Hash()[:12])
+	if got := b.String(); got != want {
+		t.Errorf("LogValue Test failed : want %s got %s", want, got)
+	}
+
+	b.Reset()
+	v.Clear()
+	for i := 0; i < 1000000; i++ {
+		v.Push(i)
+	}
+	logger.Info("large", "v", &v)
+	want = fmt.Sprintf("level=INFO msg=large v.size=1000000 v.hash=%s v.head=\"[0 1 2 3 4]\" v.tail=\"[999995 999996 999997 999998 999999]\"\n", v.Hash()[:12])
+	if got := b.String(); got != want {
+		t.Errorf("LogValue Test failed : want %s got %s", want, got)
+	}
+	if len(b.String()) > 200 || strings.Contains(b.String(), "500000") {
+		t.Errorf("LogValue Test failed : the log has too many elements, %d bytes", len(b.String()))
+	}
+
+	//a tracked hash is logged without hashing the whole vector again
+	b.Reset()
+	v.TrackHash(HashFNV)
+	v.Push(-1)
+	logger.Info("tracked", "v", &v)
+	want = fmt.Sprintf("level=INFO msg=tracked v.size=1000001 v.hash=%s v.head=\"[0 1 2 3 4]\" v.tail=\"[999996 999997 999998 999999 -1]\"\n", v.HashWith(HashFNV)[:12])
+	if got := b.String(); got != want {
+		t.Errorf("LogValue Test failed : want %s got %s", want, got)
+	}
+}
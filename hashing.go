@@ -0,0 +1,206 @@
+package intvector
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"encoding/hex"
+	"hash"
+	"hash/fnv"
+	"math/bits"
+)
+
+//HashFunc creates a new hash.Hash, it is used to plug different hashing algorithms into the vector
+type HashFunc func() hash.Hash
+
+var (
+	//HashSHA256 is the algorithm used by Hash
+	HashSHA256 HashFunc = sha256.New
+	//HashSHA512 uses sha512
+	HashSHA512 HashFunc = sha512.New
+	//HashFNV uses the 64 bit FNV-1a hash, it is fast but not cryptographically secure
+	HashFNV HashFunc = func() hash.Hash { return fnv.New64a() }
+	//HashXX uses the 64 bit xxHash with seed 0, it is fast but not cryptographically secure
+	HashXX HashFunc = func() hash.Hash { return NewXXHash64(0) }
+)
+
+//HashWith returns the hash of the serialized version of the vector using the given algorithm
+func (v *Intvector) HashWith(f HashFunc) string {
+	h := f()
+	h.Write(v.Serialized())
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+//incrementalHash is the running hash state of a vector
+//Push and Insert feed the new elements into it, every other mutation invalidates it
+type incrementalHash struct {
+	newHash HashFunc
+	h       hash.Hash
+	valid   bool
+}
+
+//appended writes the given elements into the running state, it is safe to call on a nil receiver
+func (ih *incrementalHash) appended(s ...int) {
+	if ih == nil || !ih.valid {
+		return
+	}
+	var w [8]byte
+	for _, n := range s {
+		ih.h.Write(appendWord(w[:0], n))
+	}
+}
+
+//invalidate marks the running state as stale, it is safe to call on a nil receiver
+func (ih *incrementalHash) invalidate() {
+	if ih != nil {
+		ih.valid = false
+	}
+}
+
+//TrackHash makes the vector keep a running hash state with the given algorithm.
+//Push, Insert and the other appending methods update the state in O(1) per element, any other mutation
+//makes the next call to TrackedHash rehash the whole vector once
+func (v *Intvector) TrackHash(f HashFunc) {
+	v.hashState = &incrementalHash{newHash: f}
+}
+
+//TrackedHash returns the hash of the vector using the algorithm given to TrackHash.
+//The result is the same as HashWith for that algorithm. Without TrackHash it falls back to Hash
+func (v *Intvector) TrackedHash() string {
+	ih := v.hashState
+	if ih == nil {
+		return v.Hash()
+	}
+	if !ih.valid {
+		ih.h = ih.newHash()
+		ih.h.Write(v.Serialized())
+		ih.valid = true
+	}
+	return hex.EncodeToString(ih.h.Sum(nil))
+}
+
+const (
+	xxPrime1 uint64 = 11400714785074694791
+	xxPrime2 uint64 = 14029467366897019727
+	xxPrime3 uint64 = 1609587929392839161
+	xxPrime4 uint64 = 9650029242287828579
+	xxPrime5 uint64 = 2870177450012600261
+)
+
+//XXHash64 is a streaming implementation of the 64 bit xxHash algorithm
+type XXHash64 struct {
+	seed  uint64
+	acc   [4]uint64
+	buf   [32]byte
+	nbuf  int
+	total uint64
+}
+
+//NewXXHash64 returns a new 64 bit xxHash with the given seed
+func NewXXHash64(seed uint64) *XXHash64 {
+	x := &XXHash64{seed: seed}
+	x.Reset()
+	return x
+}
+
+//Reset resets the hash to its initial state
+func (x *XXHash64) Reset() {
+	x.acc = [4]uint64{x.seed + xxPrime1 + xxPrime2, x.seed + xxPrime2, x.seed, x.seed - xxPrime1}
+	x.nbuf = 0
+	x.total = 0
+}
+
+//Size returns the number of bytes Sum will return
+func (x *XXHash64) Size() int {
+	return 8
+}
+
+//BlockSize returns the number of bytes processed at once
+func (x *XXHash64) BlockSize() int {
+	return 32
+}
+
+func xxRound(acc uint64, input uint64) uint64 {
+	acc += input * xxPrime2
+	acc = bits.RotateLeft64(acc, 31)
+	return acc * xxPrime1
+}
+
+func xxMerge(acc uint64, val uint64) uint64 {
+	acc ^= xxRound(0, val)
+	return acc*xxPrime1 + xxPrime4
+}
+
+//stripe mixes a full 32 byte stripe into the accumulators
+func (x *XXHash64) stripe(b []byte) {
+	x.acc[0] = xxRound(x.acc[0], binary.LittleEndian.Uint64(b[0:8]))
+	x.acc[1] = xxRound(x.acc[1], binary.LittleEndian.Uint64(b[8:16]))
+	x.acc[2] = xxRound(x.acc[2], binary.LittleEndian.Uint64(b[16:24]))
+	x.acc[3] = xxRound(x.acc[3], binary.LittleEndian.Uint64(b[24:32]))
+}
+
+//Write adds more data to the running hash, it never returns an error
+func (x *XXHash64) Write(b []byte) (int, error) {
+	n := len(b)
+	x.total += uint64(n)
+
+	if x.nbuf > 0 {
+		c := copy(x.buf[x.nbuf:], b)
+		x.nbuf += c
+		b = b[c:]
+		if x.nbuf < 32 {
+			return n, nil
+		}
+		x.stripe(x.buf[:])
+		x.nbuf = 0
+	}
+
+	for ; len(b) >= 32; b = b[32:] {
+		x.stripe(b)
+	}
+	x.nbuf = copy(x.buf[:], b)
+	return n, nil
+}
+
+//Sum64 returns the current hash
+func (x *XXHash64) Sum64() uint64 {
+	var h uint64
+	if x.total >= 32 {
+		h = bits.RotateLeft64(x.acc[0], 1) + bits.RotateLeft64(x.acc[1], 7) + bits.RotateLeft64(x.acc[2], 12) + bits.RotateLeft64(x.acc[3], 18)
+		for _, acc := range x.acc {
+			h = xxMerge(h, acc)
+		}
+	} else {
+		h = x.seed + xxPrime5
+	}
+	h += x.total
+
+	b := x.buf[:x.nbuf]
+	for ; len(b) >= 8; b = b[8:] {
+		h ^= xxRound(0, binary.LittleEndian.Uint64(b))
+		h = bits.RotateLeft64(h, 27)*xxPrime1 + xxPrime4
+	}
+	if len(b) >= 4 {
+		h ^= uint64(binary.LittleEndian.Uint32(b)) * xxPrime1
+		h = bits.RotateLeft64(h, 23)*xxPrime2 + xxPrime3
+		b = b[4:]
+	}
+	for _, c := range b {
+		h ^= uint64(c) * xxPrime5
+		h = bits.RotateLeft64(h, 11) * xxPrime1
+	}
+
+	h ^= h >> 33
+	h *= xxPrime2
+	h ^= h >> 29
+	h *= xxPrime3
+	h ^= h >> 32
+	return h
+}
+
+//Sum appends the current hash to b in big endian order and returns the resulting slice
+func (x *XXHash64) Sum(b []byte) []byte {
+	var s [8]byte
+	binary.BigEndian.PutUint64(s[:], x.Sum64())
+	return append(b, s[:]...)
+}
@@ -0,0 +1,125 @@
+package intvector
+
+import (
+	"encoding/hex"
+	"testing"
+)
+
+func TestHashWith(t *testing.T) {
+	var s Intvector
+	for i := 0; i <= 10; i++ {
+		s.Push(i)
+	}
+
+	//HashWith sha256 must agree with Hash
+	if want, got := s.Hash(), s.HashWith(HashSHA256); want != got {
+		t.Errorf("HashWith Test failed : want %s got %s", want, got)
+	}
+
+	wantLengths := map[string]int{"sha512": 128, "fnv": 16, "xx": 16}
+	for name, f := range map[string]HashFunc{"sha512": HashSHA512, "fnv": HashFNV, "xx": HashXX} {
+		got := s.HashWith(f)
+		if len(got) != wantLengths[name] {
+			t.Errorf("HashWith Test failed : want %d hex characters for %s got %s", wantLengths[name], name, got)
+		}
+		if got == s.HashWith(HashSHA256) {
+			t.Errorf("HashWith Test failed : %s returned the sha256 hash", name)
+		}
+	}
+}
+
+func TestXXHash64(t *testing.T) {
+	//reference values of the 64 bit xxHash with seed 0
+	tests := []struct {
+		in   string
+		want uint64
+	}{
+		{"", 0xef46db3751d8e999},
+		{"a", 0xd24ec4f1a98c6e5b},
+		{"abc", 0x44bc2cf5ad770999},
+	}
+	for _, test := range tests {
+		x := NewXXHash64(0)
+		x.Write([]byte(test.in))
+		if got := x.Sum64(); got != test.want {
+			t.Errorf("XXHash64 Test failed : want %x got %x for %q", test.want, got, test.in)
+		}
+	}
+
+	//writing in pieces must give the same result as writing at once
+	data := make([]byte, 300)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	whole := NewXXHash64(42)
+	whole.Write(data)
+	for _, step := range []int{1, 5, 31, 32, 33, 100} {
+		pieces := NewXXHash64(42)
+		for i := 0; i < len(data); i += step {
+			end := i + step
+			if end > len(data) {
+				end = len(data)
+			}
+			pieces.Write(data[i:end])
+		}
+		if want, got := hex.EncodeToString(whole.Sum(nil)), hex.EncodeToString(pieces.Sum(nil)); want != got {
+			t.Errorf("XXHash64 Test failed : want %s got %s when writing %d bytes at a time", want, got, step)
+		}
+	}
+}
+
+func TestTrackedHash(t *testing.T) {
+	var s Intvector
+
+	//without TrackHash it falls back to Hash
+	s.Push(1)
+	if want, got := s.Hash(), s.TrackedHash(); want != got {
+		t.Errorf("TrackedHash Test failed : want %s got %s", want, got)
+	}
+
+	s.TrackHash(HashSHA512)
+	check := func(step string) {
+		if want, got := s.HashWith(HashSHA512), s.TrackedHash(); want != got {
+			t.Errorf("TrackedHash Test failed after %s : want %s got %s", step, want, got)
+		}
+	}
+
+	check("TrackHash")
+	s.Push(2)
+	check("Push")
+	s.Insert(3, 4, 5)
+	check("Insert")
+	s.UniquePush(6)
+	check("UniquePush")
+	s.Pop()
+	check("Pop")
+	s.Push(7)
+	check("Push after Pop")
+	s.Set(0, 9)
+	check("Set")
+	s.Reverse()
+	check("Reverse")
+	s.Sort()
+	check("Sort")
+	s.RemoveAt(2)
+	check("RemoveAt")
+	s.Shift()
+	check("Shift")
+	s.Unshift(4)
+	check("Unshift")
+	s.ScaleBy(3)
+	check("ScaleBy")
+	s.Clear()
+	check("Clear")
+	s.Insert(1, 2)
+	check("Insert after Clear")
+}
+
+func BenchmarkTrackedHashPush(b *testing.B) {
+	var s Intvector
+	s.TrackHash(HashSHA256)
+	for i := 0; i < b.N; i++ {
+		s.Push(i)
+		s.TrackedHash()
+	}
+}
@@ -0,0 +1,208 @@
+package intvector
+
+import (
+	"errors"
+)
+
+//editKind identifies the kind of change an edit describes
+type editKind byte
+
+const (
+	//editInsert inserted vals at idx
+	editInsert editKind = iota + 1
+	//editRemove removed vals from idx on
+	editRemove
+	//editSet changed the element at idx from old to new
+	editSet
+	//editSwap swapped the elements at idx and idx2
+	editSwap
+	//editReverse reversed the whole vector
+	editReverse
+	//editReplace replaced the whole contents before with vals
+	editReplace
+	//editReorder is an editReplace where vals holds the same elements as before in another order
+	editReorder
+)
+
+//edit describes a single change made by a mutating method, it holds enough to undo and redo it
+type edit struct {
+	kind     editKind
+	idx      int
+	idx2     int
+	old, new int
+	vals     []int
+	before   []int
+}
+
+//history holds the edits that can be undone and redone
+type history struct {
+	limit int
+	undo  []edit
+	redo  []edit
+}
+
+//EnableHistory starts recording every mutating call so it can be undone, at most limit calls are kept.
+//A limit of 0 or less turns the history off and drops it
+func (v *Intvector) EnableHistory(limit int) {
+	if limit <= 0 {
+		v.history = nil
+		return
+	}
+	v.history = &history{limit: limit}
+}
+
+//recording returns true if the mutating methods need to describe their changes
+func (v *Intvector) recording() bool {
+	return v.history != nil || len(v.observers) > 0
+}
+
+//record passes an edit that has just been applied to the history and the observers
+func (v *Intvector) record(e edit) {
+	if v.history != nil {
+		v.history.add(e)
+	}
+	v.notify(e)
+}
+
+//add adds an edit to the history, dropping the oldest one if the limit is reached
+//A new edit makes the undone ones impossible to redo
+func (h *history) add(e edit) {
+	if len(h.undo) == h.limit {
+		copy(h.undo, h.undo[1:])
+		h.undo = h.undo[:len(h.undo)-1]
+	}
+	h.undo = append(h.undo, e)
+	h.redo = nil
+}
+
+//replace sets the whole contents of the vector to s, which must not be used by the caller afterwards
+func (v *Intvector) replace(s []int) {
+	if v.recording() {
+		//the old backing array is no longer used by the vector, so the history can keep it without a copy
+		v.record(edit{kind: editReplace, before: v.vec, vals: append([]int{}, s...)})
+	}
+	v.vec = s
+	v.shared = false
+	v.hashState.invalidate()
+}
+
+//CanUndo returns true if there is a call that Undo can revert
+func (v *Intvector) CanUndo() bool {
+	return v.history != nil && len(v.history.undo) > 0
+}
+
+//CanRedo returns true if there is an undone call that Redo can apply again
+func (v *Intvector) CanRedo() bool {
+	return v.history != nil && len(v.history.redo) > 0
+}
+
+//Undo reverts the last recorded mutating call
+func (v *Intvector) Undo() error {
+	if !v.CanUndo() {
+		return errors.New("Nothing to undo")
+	}
+	h := v.history
+	e := h.undo[len(h.undo)-1]
+	h.undo = h.undo[:len(h.undo)-1]
+	v.applyEdit(e, true)
+	h.redo = append(h.redo, e)
+	return nil
+}
+
+//Redo applies the last undone call again
+func (v *Intvector) Redo() error {
+	if !v.CanRedo() {
+		return errors.New("Nothing to redo")
+	}
+	h := v.history
+	e := h.redo[len(h.redo)-1]
+	h.redo = h.redo[:len(h.redo)-1]
+	v.applyEdit(e, false)
+	h.undo = append(h.undo, e)
+	return nil
+}
+
+//inverse returns the edit that reverts e
+func (e edit) inverse() edit {
+	switch e.kind {
+	case editInsert:
+		e.kind = editRemove
+	case editRemove:
+		e.kind = editInsert
+	case editSet:
+		e.old, e.new = e.new, e.old
+	case editReplace, editReorder:
+		e.before, e.vals = e.vals, e.before
+	}
+	return e
+}
+
+//applyEdit applies an edit to the vector, or reverts it if undo is true
+func (v *Intvector) applyEdit(e edit, undo bool) {
+	if undo {
+		e = e.inverse()
+	}
+
+	switch e.kind {
+	case editInsert:
+		v.own()
+		v.vec = append(v.vec[:e.idx], append(append([]int{}, e.vals...), v.vec[e.idx:]...)...)
+	case editRemove:
+		v.own()
+		v.vec = append(v.vec[:e.idx], v.vec[e.idx+len(e.vals):]...)
+	case editSet:
+		v.own()
+		v.vec[e.idx] = e.new
+		for _, f := range v.setHooks {
+			f(e.idx, e.new)
+		}
+	case editSwap:
+		v.own()
+		v.vec[e.idx], v.vec[e.idx2] = v.vec[e.idx2], v.vec[e.idx]
+	case editReverse:
+		v.own()
+		for i := 0; i < len(v.vec)/2; i++ {
+			v.vec[i], v.vec[len(v.vec)-1-i] = v.vec[len(v.vec)-1-i], v.vec[i]
+		}
+	case editReplace, editReorder:
+		v.vec = e.vals
+		//the history keeps using the array, so it must be copied before the vector writes to it
+		v.shared = true
+	}
+	v.hashState.invalidate()
+	v.notify(e)
+}
+
+//own makes sure the vector is the only user of its backing array before it is written to
+//The array is copied once after a Clone, Snapshot or Restore, later writes are free again
+func (v *Intvector) own() {
+	if v.shared {
+		v.vec = append([]int(nil), v.vec...)
+		v.shared = false
+	}
+}
+
+//Clone returns a copy of the vector in O(1), the two share their elements until either of them changes them.
+//Only the elements are copied, hooks, history and hash tracking are not
+func (v *Intvector) Clone() *Intvector {
+	v.shared = true
+	//the capacity is clipped so that appending to the clone can not write into the shared array
+	return &Intvector{vec: v.vec[:len(v.vec):len(v.vec)], shared: true}
+}
+
+//Snapshot is Clone under a name that reads better when the copy is kept to Restore it later
+func (v *Intvector) Snapshot() *Intvector {
+	return v.Clone()
+}
+
+//Restore sets the contents of the vector to those of the snapshot in O(1), the snapshot stays valid
+//It is recorded in the history like any other mutating call
+func (v *Intvector) Restore(snapshot *Intvector) {
+	s := snapshot.Clone()
+	if v.recording() {
+		v.record(edit{kind: editReplace, before: v.vec, vals: s.vec})
+	}
+	v.vec = s.vec
+	v.shared = true
+	v.hashState.invalidate()
+}
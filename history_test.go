@@ -0,0 +1,186 @@
+package intvector
+
+import (
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	var s Intvector
+	s.Insert(1, 2, 3, 4)
+	c := s.Clone()
+
+	//changing either one must not change the other
+	s.Set(0, 100)
+	c.Push(5)
+	c.Set(1, 200)
+	s.Pop()
+	s.Push(300)
+
+	wantS := []int{100, 2, 3, 300}
+	wantC := []int{1, 200, 3, 4, 5}
+	for i, want := range wantS {
+		if got, _ := s.At(i); got != want {
+			t.Errorf("Clone Test failed : original want %d at index %d got %d", want, i, got)
+		}
+	}
+	for i, want := range wantC {
+		if got, _ := c.At(i); got != want {
+			t.Errorf("Clone Test failed : clone want %d at index %d got %d", want, i, got)
+		}
+	}
+	if s.Size() != len(wantS) || c.Size() != len(wantC) {
+		t.Errorf("Clone Test failed : want sizes %d and %d got %d and %d", len(wantS), len(wantC), s.Size(), c.Size())
+	}
+
+	//the clone must not copy anything up front
+	var big Intvector
+	for i := 0; i < 1000; i++ {
+		big.Push(i)
+	}
+	if allocs := testing.AllocsPerRun(100, func() { big.Clone() }); allocs > 1 {
+		t.Errorf("Clone Test failed : want at most 1 allocation got %f", allocs)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	var s Intvector
+	s.Insert(3, 1, 2)
+	snap := s.Snapshot()
+	hash := s.Hash()
+
+	s.Sort()
+	s.RemoveAt(0)
+	s.Unshift(9)
+	s.Restore(snap)
+
+	if s.Hash() != hash {
+		t.Error("Snapshot Test failed : Restore did not bring back the snapshot")
+	}
+	s.Set(0, 7)
+	if got, _ := snap.At(0); got != 3 {
+		t.Errorf("Snapshot Test failed : snapshot changed after Restore, want %d got %d", 3, got)
+	}
+}
+
+func TestUndoRedo(t *testing.T) {
+	var s Intvector
+	s.EnableHistory(100)
+
+	steps := []func(){
+		func() { s.Push(5) },
+		func() { s.Insert(3, 8, 1, 8) },
+		func() { s.Unshift(2) },
+		func() { s.Set(2, 7) },
+		func() { s.Swap(0, 4) },
+		func() { s.SortedPush(4) },
+		func() { s.UniquePush(6) },
+		func() { s.Pop() },
+		func() { s.Shift() },
+		func() { s.RemoveAt(1) },
+		func() { s.RemoveFirstOf(8) },
+		func() { s.Reverse() },
+		func() { s.Push(5) },
+		func() { s.RemoveAll(5) },
+		func() { s.Insert(1, 1) },
+		func() { s.MakeUnique() },
+		func() { s.Sort() },
+		func() { s.ScaleBy(3) },
+		func() { s.DeserializeFrom(appendWords(nil, []int{9, 9}), false) },
+		func() { s.DeserializeFrom(appendWords(nil, []int{4}), true) },
+		func() { s.Clear() },
+	}
+
+	hashes := []string{s.Hash()}
+	for _, step := range steps {
+		step()
+		hashes = append(hashes, s.Hash())
+	}
+
+	for i := len(steps) - 1; i >= 0; i-- {
+		if err := s.Undo(); err != nil {
+			t.Fatalf("Undo Test failed : Undo returned error %s at step %d", err, i)
+		}
+		if s.Hash() != hashes[i] {
+			t.Errorf("Undo Test failed : wrong contents after undoing step %d", i)
+		}
+	}
+	if err := s.Undo(); err == nil {
+		t.Error("Undo Test failed : should return error with nothing to undo")
+	}
+
+	for i := 1; i <= len(steps); i++ {
+		if err := s.Redo(); err != nil {
+			t.Fatalf("Redo Test failed : Redo returned error %s at step %d", err, i)
+		}
+		if s.Hash() != hashes[i] {
+			t.Errorf("Redo Test failed : wrong contents after redoing step %d", i)
+		}
+	}
+	if err := s.Redo(); err == nil {
+		t.Error("Redo Test failed : should return error with nothing to redo")
+	}
+}
+
+func TestUndoSharing(t *testing.T) {
+	var s Intvector
+	s.EnableHistory(10)
+	s.Insert(1, 2, 3)
+	s.Sort()
+	s.Reverse()
+
+	//write to the vector after redoing a replacement, the history must not see it
+	s.Undo()
+	s.Undo()
+	s.Redo()
+	s.Set(0, 50)
+	s.Undo()
+	s.Undo()
+	want := s.Hash()
+	s.Redo()
+	s.Undo()
+	if s.Hash() != want {
+		t.Error("Undo Test failed : history was changed by a write to the vector")
+	}
+}
+
+func TestHistoryLimit(t *testing.T) {
+	var s Intvector
+	s.EnableHistory(3)
+	for i := 0; i < 10; i++ {
+		s.Push(i)
+	}
+
+	count := 0
+	for s.CanUndo() {
+		s.Undo()
+		count++
+	}
+	if count != 3 || s.Size() != 7 {
+		t.Errorf("History Limit Test failed : want 3 undos down to size 7 got %d undos and size %d", count, s.Size())
+	}
+
+	//a new call drops the redo entries
+	s.Push(42)
+	if s.CanRedo() {
+		t.Error("History Limit Test failed : CanRedo is true after a new call")
+	}
+
+	s.EnableHistory(0)
+	if s.CanUndo() {
+		t.Error("History Limit Test failed : CanUndo is true after turning the history off")
+	}
+}
+
+func TestUndoSetHooks(t *testing.T) {
+	var s Intvector
+	s.Insert(1, 2, 3)
+	s.EnableHistory(5)
+	tree := NewSegmentTree(&s)
+	s.OnSet(func(idx int, value int) { tree.Set(idx, value) })
+
+	s.Set(1, 10)
+	s.Undo()
+	if sum, _ := tree.Sum(0, 3); sum != 6 {
+		t.Errorf("Undo Test failed : segment tree missed the undone Set, want sum %d got %d", 6, sum)
+	}
+}
@@ -0,0 +1,468 @@
+//Package httpapi serves named vectors over HTTP with JSON bodies.
+//
+//The routes are relative to where the handler is mounted, use http.StripPrefix to mount it below a path:
+//	GET    /                     names of all vectors
+//	GET    /{name}               elements as a JSON array
+//	PUT    /{name}               replace the elements with a JSON array, creating the vector if needed
+//	POST   /{name}               push the elements of a JSON array
+//	DELETE /{name}               delete the vector
+//	GET    /{name}/stats         size, min, max, mean, median, mode, modes and frequency
+//	GET    /{name}/serialized    the Serialized form as application/octet-stream
+//	PUT    /{name}/serialized    replace the elements with a Serialized body
+//	GET    /{name}/{idx}         a single element
+//	PUT    /{name}/{idx}         set a single element to a JSON number
+//	DELETE /{name}/{idx}         remove a single element
+//
+//Every response about a vector carries its Hash as the ETag. Changes can be made conditional with If-Match,
+//which fails with 412 Precondition Failed if the vector has changed since the ETag was read, and reads can
+//use If-None-Match to get 304 Not Modified
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ankitshah86/intvector"
+)
+
+//maxBody is the largest request body accepted
+const maxBody = 64 << 20
+
+//Handler is an http.Handler serving a collection of named vectors
+type Handler struct {
+	mu      sync.Mutex
+	vectors map[string]*intvector.Intvector
+}
+
+//NewHandler returns a handler without any vectors
+func NewHandler() *Handler {
+	return &Handler{vectors: make(map[string]*intvector.Intvector)}
+}
+
+//Do calls f with the named vector, creating it if needed. No request is served while f runs,
+//so f can read and change the vector but must not keep it
+func (h *Handler) Do(name string, f func(v *intvector.Intvector)) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	v, ok := h.vectors[name]
+	if !ok {
+		v = &intvector.Intvector{}
+		h.vectors[name] = v
+	}
+	f(v)
+}
+
+//Stats is the body of GET /{name}/stats, Min, Max and Mode are null for an empty vector and Mode is also null
+//if there is more than one mode. Modes has all the values with the highest frequency in ascending order
+type Stats struct {
+	Size      int         `json:"size"`
+	Min       *int        `json:"min"`
+	Max       *int        `json:"max"`
+	Mean      float64     `json:"mean"`
+	Median    float64     `json:"median"`
+	Mode      *int        `json:"mode"`
+	Modes     []int       `json:"modes"`
+	Frequency map[int]int `json:"frequency"`
+}
+
+//httpError is an error with the status code it is reported with
+type httpError struct {
+	code int
+	msg  string
+}
+
+func (e *httpError) Error() string {
+	return e.msg
+}
+
+var (
+	errNotFound     = &httpError{http.StatusNotFound, "Vector not found"}
+	errPrecondition = &httpError{http.StatusPreconditionFailed, "Vector has changed"}
+)
+
+//ServeHTTP serves a request, see the package documentation for the routes
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if len(parts) > 2 || parts[0] == "" && len(parts) > 1 {
+		writeError(w, &httpError{http.StatusNotFound, "Not found"})
+		return
+	}
+
+	idx := 0
+	if len(parts) == 2 && parts[1] != "stats" && parts[1] != "serialized" {
+		var err error
+		if idx, err = strconv.Atoi(parts[1]); err != nil {
+			writeError(w, &httpError{http.StatusNotFound, "Not found"})
+			return
+		}
+	}
+
+	//the body is read and decoded and the response is buffered outside of the lock,
+	//so that a slow client only holds up its own request
+	b, err := readBody(r, parts)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	res := &response{header: http.Header{}}
+
+	h.mu.Lock()
+	switch {
+	case parts[0] == "":
+		err = h.list(res, r)
+	case len(parts) == 1:
+		err = h.vector(res, r, parts[0], b)
+	case parts[1] == "stats":
+		err = h.stats(res, r, parts[0])
+	case parts[1] == "serialized":
+		err = h.serialized(res, r, parts[0], b)
+	default:
+		err = h.element(res, r, parts[0], idx, b)
+	}
+	h.mu.Unlock()
+
+	if err != nil {
+		writeError(res, err)
+	}
+	res.send(w)
+}
+
+//response is a response buffered while the lock is held
+type response struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (res *response) Header() http.Header {
+	return res.header
+}
+
+func (res *response) WriteHeader(code int) {
+	if res.code == 0 {
+		res.code = code
+	}
+}
+
+func (res *response) Write(b []byte) (int, error) {
+	res.WriteHeader(http.StatusOK)
+	return res.body.Write(b)
+}
+
+//send writes the buffered response to w
+func (res *response) send(w http.ResponseWriter) {
+	for k, v := range res.header {
+		w.Header()[k] = v
+	}
+	if res.code == 0 {
+		res.code = http.StatusOK
+	}
+	w.WriteHeader(res.code)
+	w.Write(res.body.Bytes())
+}
+
+//body is the decoded body of a request that has one
+type body struct {
+	//ints are the elements of PUT and POST /{name} and PUT /{name}/serialized
+	ints []int
+	//n is the element of PUT /{name}/{idx}
+	n int
+}
+
+//readBody reads and decodes the body of the request for the route in parts
+func readBody(r *http.Request, parts []string) (body, error) {
+	var b body
+	switch {
+	case parts[0] == "":
+	case len(parts) == 1 && (r.Method == http.MethodPut || r.Method == http.MethodPost):
+		return b, readJSON(r, &b.ints)
+	case len(parts) == 2 && parts[1] == "serialized" && r.Method == http.MethodPut:
+		raw, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, maxBody))
+		if err != nil {
+			return b, err
+		}
+		//decode into a new vector first so that an invalid body leaves the vector alone
+		tmp := &intvector.Intvector{}
+		if len(raw) > 0 {
+			if err := tmp.DeserializeFrom(raw, false); err != nil {
+				return b, err
+			}
+		}
+		b.ints = elements(tmp)
+	case len(parts) == 2 && parts[1] != "stats" && r.Method == http.MethodPut:
+		return b, readJSON(r, &b.n)
+	}
+	return b, nil
+}
+
+//writeError writes err as a JSON object with an error field
+func writeError(w http.ResponseWriter, err error) {
+	code := http.StatusBadRequest
+	var he *httpError
+	if errors.As(err, &he) {
+		code = he.code
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{"error": err.Error()})
+}
+
+//writeJSON writes body as JSON, with the ETag of v if it is not nil
+func writeJSON(w http.ResponseWriter, code int, v *intvector.Intvector, body interface{}) error {
+	if v != nil {
+		w.Header().Set("ETag", etag(v))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	return json.NewEncoder(w).Encode(body)
+}
+
+//methodNotAllowed reports a method the route does not support
+func methodNotAllowed(w http.ResponseWriter, allow string) error {
+	w.Header().Set("Allow", allow)
+	return &httpError{http.StatusMethodNotAllowed, "Method not allowed"}
+}
+
+//etag returns the ETag of the vector, its hash as a strong validator
+func etag(v *intvector.Intvector) string {
+	return `"` + v.Hash() + `"`
+}
+
+//matches returns true if the ETag of v is in the list of the header, "*" matches every existing vector.
+//A weak ETag only matches with weak comparison, which If-None-Match uses and If-Match does not
+func matches(header string, v *intvector.Intvector, weak bool) bool {
+	if v == nil {
+		return false
+	}
+	tag := etag(v)
+	for _, t := range strings.Split(header, ",") {
+		t = strings.TrimSpace(t)
+		if weak {
+			t = strings.TrimPrefix(t, "W/")
+		}
+		if t == "*" || t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+//checkIfMatch returns errPrecondition if the request has an If-Match header that does not match v,
+//v is nil for a vector that does not exist
+func checkIfMatch(r *http.Request, v *intvector.Intvector) error {
+	if header := r.Header.Get("If-Match"); header != "" && !matches(header, v, false) {
+		return errPrecondition
+	}
+	return nil
+}
+
+//notModified writes 304 Not Modified and returns true if the If-None-Match header of the request matches v
+func notModified(w http.ResponseWriter, r *http.Request, v *intvector.Intvector) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" && matches(header, v, true) {
+		w.Header().Set("ETag", etag(v))
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+//readJSON decodes the request body into dst
+func readJSON(r *http.Request, dst interface{}) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxBody))
+	if err := dec.Decode(dst); err != nil {
+		return &httpError{http.StatusBadRequest, "Invalid body: " + err.Error()}
+	}
+	if dec.More() {
+		return &httpError{http.StatusBadRequest, "Invalid body: trailing data"}
+	}
+	return nil
+}
+
+//elements returns all the elements of v
+func elements(v *intvector.Intvector) []int {
+	s := make([]int, v.Size())
+	for i := range s {
+		s[i], _ = v.At(i)
+	}
+	return s
+}
+
+//assign replaces the elements of v with s in a single step
+func assign(v *intvector.Intvector, s []int) {
+	v.Tx(func(tx *intvector.IntvectorTx) error {
+		tx.Clear()
+		tx.Insert(s...)
+		return nil
+	})
+}
+
+//list serves /
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return methodNotAllowed(w, "GET, HEAD")
+	}
+	names := make([]string, 0, len(h.vectors))
+	for name := range h.vectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return writeJSON(w, http.StatusOK, nil, names)
+}
+
+//vector serves /{name}
+func (h *Handler) vector(w http.ResponseWriter, r *http.Request, name string, b body) error {
+	v := h.vectors[name]
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if v == nil {
+			return errNotFound
+		}
+		if notModified(w, r, v) {
+			return nil
+		}
+		return writeJSON(w, http.StatusOK, v, elements(v))
+
+	case http.MethodPut:
+		if err := checkIfMatch(r, v); err != nil {
+			return err
+		}
+		code := http.StatusOK
+		if v == nil {
+			v, code = &intvector.Intvector{}, http.StatusCreated
+			h.vectors[name] = v
+		}
+		assign(v, b.ints)
+		return writeJSON(w, code, v, elements(v))
+
+	case http.MethodPost:
+		if err := checkIfMatch(r, v); err != nil {
+			return err
+		}
+		code := http.StatusOK
+		if v == nil {
+			v, code = &intvector.Intvector{}, http.StatusCreated
+			h.vectors[name] = v
+		}
+		v.Insert(b.ints...)
+		return writeJSON(w, code, v, elements(v))
+
+	case http.MethodDelete:
+		if v == nil {
+			return errNotFound
+		}
+		if err := checkIfMatch(r, v); err != nil {
+			return err
+		}
+		delete(h.vectors, name)
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	}
+	return methodNotAllowed(w, "GET, HEAD, PUT, POST, DELETE")
+}
+
+//stats serves /{name}/stats
+func (h *Handler) stats(w http.ResponseWriter, r *http.Request, name string) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return methodNotAllowed(w, "GET, HEAD")
+	}
+	v := h.vectors[name]
+	if v == nil {
+		return errNotFound
+	}
+	if notModified(w, r, v) {
+		return nil
+	}
+
+	st := Stats{Size: v.Size(), Mean: v.Mean(), Median: v.Median(), Modes: []int{}, Frequency: v.Frequency()}
+	if !v.IsEmpty() {
+		min, _ := v.Min()
+		max, _ := v.Max()
+		st.Min, st.Max = &min, &max
+		//Mode fails for a multimodal vector and Modes for a unimodal one
+		if mode, err := v.Mode(); err == nil {
+			st.Mode, st.Modes = &mode, []int{mode}
+		} else {
+			st.Modes, _ = v.Modes()
+			sort.Ints(st.Modes)
+		}
+	}
+	return writeJSON(w, http.StatusOK, v, st)
+}
+
+//serialized serves /{name}/serialized
+func (h *Handler) serialized(w http.ResponseWriter, r *http.Request, name string, b body) error {
+	v := h.vectors[name]
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		if v == nil {
+			return errNotFound
+		}
+		if notModified(w, r, v) {
+			return nil
+		}
+		raw := v.Serialized()
+		w.Header().Set("ETag", etag(v))
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Length", strconv.Itoa(len(raw)))
+		w.WriteHeader(http.StatusOK)
+		_, err := w.Write(raw)
+		return err
+
+	case http.MethodPut:
+		if err := checkIfMatch(r, v); err != nil {
+			return err
+		}
+		code := http.StatusOK
+		if v == nil {
+			v, code = &intvector.Intvector{}, http.StatusCreated
+			h.vectors[name] = v
+		}
+		assign(v, b.ints)
+		w.Header().Set("ETag", etag(v))
+		w.WriteHeader(code)
+		return nil
+	}
+	return methodNotAllowed(w, "GET, HEAD, PUT")
+}
+
+//element serves /{name}/{idx}
+func (h *Handler) element(w http.ResponseWriter, r *http.Request, name string, idx int, b body) error {
+	v := h.vectors[name]
+	if v == nil {
+		return errNotFound
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		if r.Method != http.MethodPut && r.Method != http.MethodDelete {
+			return methodNotAllowed(w, "GET, HEAD, PUT, DELETE")
+		}
+		if err := checkIfMatch(r, v); err != nil {
+			return err
+		}
+	}
+	if _, err := v.At(idx); err != nil {
+		return &httpError{http.StatusNotFound, err.Error()}
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		v.Set(idx, b.n)
+	case http.MethodDelete:
+		v.RemoveAt(idx)
+		w.Header().Set("ETag", etag(v))
+		w.WriteHeader(http.StatusNoContent)
+		return nil
+	default:
+		if notModified(w, r, v) {
+			return nil
+		}
+	}
+	n, _ := v.At(idx)
+	return writeJSON(w, http.StatusOK, v, n)
+}
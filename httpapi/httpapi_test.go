@@ -0,0 +1,264 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ankitshah86/intvector"
+)
+
+//do serves a request and returns the response
+func do(t *testing.T, h http.Handler, method string, path string, body string, header ...string) *http.Response {
+	t.Helper()
+	r := httptest.NewRequest(method, path, strings.NewReader(body))
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Result()
+}
+
+//text returns the body of the response without the trailing newline
+func text(t *testing.T, res *http.Response) string {
+	t.Helper()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSuffix(string(b), "\n")
+}
+
+func TestCRUD(t *testing.T) {
+	h := NewHandler()
+
+	steps := []struct {
+		method string
+		path   string
+		body   string
+		code   int
+		want   string
+	}{
+		{"GET", "/a", "", 404, `{"error":"Vector not found"}`},
+		{"PUT", "/a", "[3,1,2]", 201, "[3,1,2]"},
+		{"POST", "/a", "[7]", 200, "[3,1,2,7]"},
+		{"POST", "/b", "[1]", 201, "[1]"},
+		{"GET", "/", "", 200, `["a","b"]`},
+		{"GET", "/a/1", "", 200, "1"},
+		{"PUT", "/a/1", "9", 200, "9"},
+		{"DELETE", "/a/0", "", 204, ""},
+		{"GET", "/a", "", 200, "[9,2,7]"},
+		{"GET", "/a/3", "", 404, `{"error":"Index out of bounds"}`},
+		{"GET", "/a/x", "", 404, `{"error":"Not found"}`},
+		{"PUT", "/a", "[1,", 400, ""},
+		{"PUT", "/a", "[1] [2]", 400, `{"error":"Invalid body: trailing data"}`},
+		{"PATCH", "/a", "", 405, `{"error":"Method not allowed"}`},
+		{"PUT", "/a", "[]", 200, "[]"},
+		{"DELETE", "/b", "", 204, ""},
+		{"DELETE", "/b", "", 404, `{"error":"Vector not found"}`},
+		{"GET", "/", "", 200, `["a"]`},
+		{"GET", "/a/b/c", "", 404, `{"error":"Not found"}`},
+	}
+	for _, s := range steps {
+		res := do(t, h, s.method, s.path, s.body)
+		got := text(t, res)
+		if res.StatusCode != s.code || s.want != "" && got != s.want {
+			t.Errorf("CRUD Test failed : %s %s want %d %s got %d %s", s.method, s.path, s.code, s.want, res.StatusCode, got)
+		}
+	}
+
+	if res := do(t, h, "PATCH", "/a", ""); res.Header.Get("Allow") == "" {
+		t.Error("CRUD Test failed : want an Allow header")
+	}
+	h.Do("a", func(v *intvector.Intvector) {
+		if v.Size() != 0 {
+			t.Errorf("CRUD Test failed : want 0 elements got %d", v.Size())
+		}
+	})
+}
+
+func TestStats(t *testing.T) {
+	h := NewHandler()
+	h.Do("a", func(v *intvector.Intvector) {
+		v.Insert(4, 1, 4, 9, 1)
+	})
+	res := do(t, h, "GET", "/a/stats", "")
+	var st Stats
+	if err := json.NewDecoder(res.Body).Decode(&st); err != nil {
+		t.Fatal(err)
+	}
+	want := Stats{Size: 5, Mean: 3.8, Median: 4, Modes: []int{1, 4}, Frequency: map[int]int{1: 2, 4: 2, 9: 1}}
+	min, max := 1, 9
+	want.Min, want.Max = &min, &max
+	if !reflect.DeepEqual(st, want) {
+		t.Errorf("Stats Test failed : want %+v got %+v", want, st)
+	}
+
+	h.Do("b", func(v *intvector.Intvector) {})
+	if got := text(t, do(t, h, "GET", "/b/stats", "")); got != `{"size":0,"min":null,"max":null,"mean":0,"median":0,"mode":null,"modes":[],"frequency":{}}` {
+		t.Errorf("Stats Test failed : got %s for an empty vector", got)
+	}
+	h.Do("c", func(v *intvector.Intvector) {
+		v.Insert(2)
+	})
+	if got := text(t, do(t, h, "GET", "/c/stats", "")); got != `{"size":1,"min":2,"max":2,"mean":2,"median":2,"mode":2,"modes":[2],"frequency":{"2":1}}` {
+		t.Errorf("Stats Test failed : got %s for a single element", got)
+	}
+}
+
+func TestSerialized(t *testing.T) {
+	h := NewHandler()
+	var want []byte
+	h.Do("a", func(v *intvector.Intvector) {
+		v.Insert(5, -3, 8)
+		want = v.Serialized()
+	})
+
+	res := do(t, h, "GET", "/a/serialized", "")
+	got, _ := io.ReadAll(res.Body)
+	if res.Header.Get("Content-Type") != "application/octet-stream" || !bytes.Equal(got, want) {
+		t.Errorf("Serialized Test failed : want %v got %s %v", want, res.Header.Get("Content-Type"), got)
+	}
+
+	if res := do(t, h, "PUT", "/b/serialized", string(want)); res.StatusCode != 201 {
+		t.Errorf("Serialized Test failed : want 201 got %d", res.StatusCode)
+	}
+	if got := text(t, do(t, h, "GET", "/b", "")); got != "[5,-3,8]" {
+		t.Errorf("Serialized Test failed : want [5,-3,8] got %s", got)
+	}
+
+	//an invalid body leaves the vector alone
+	if res := do(t, h, "PUT", "/b/serialized", "abc"); res.StatusCode != 400 {
+		t.Errorf("Serialized Test failed : want 400 got %d", res.StatusCode)
+	}
+	if got := text(t, do(t, h, "GET", "/b", "")); got != "[5,-3,8]" {
+		t.Errorf("Serialized Test failed : want [5,-3,8] got %s", got)
+	}
+}
+
+func TestConditional(t *testing.T) {
+	h := NewHandler()
+	res := do(t, h, "PUT", "/a", "[1,2]")
+	tag := res.Header.Get("ETag")
+	var hash string
+	h.Do("a", func(v *intvector.Intvector) {
+		hash = v.Hash()
+	})
+	if tag != `"`+hash+`"` {
+		t.Fatalf("Conditional Test failed : want ETag %q got %q", `"`+hash+`"`, tag)
+	}
+
+	if res := do(t, h, "GET", "/a", "", "If-None-Match", tag); res.StatusCode != 304 {
+		t.Errorf("Conditional Test failed : want 304 got %d", res.StatusCode)
+	}
+	//If-None-Match uses weak comparison, If-Match strong comparison
+	if res := do(t, h, "GET", "/a", "", "If-None-Match", "W/"+tag); res.StatusCode != 304 {
+		t.Errorf("Conditional Test failed : want 304 for a weak tag got %d", res.StatusCode)
+	}
+	if res := do(t, h, "POST", "/a", "[3]", "If-Match", "W/"+tag); res.StatusCode != 412 {
+		t.Errorf("Conditional Test failed : want 412 for a weak tag got %d", res.StatusCode)
+	}
+
+	//two clients read the same version, only the first update succeeds
+	res = do(t, h, "POST", "/a", "[3]", "If-Match", tag)
+	if res.StatusCode != 200 || res.Header.Get("ETag") == tag {
+		t.Errorf("Conditional Test failed : want 200 and a new ETag got %d %s", res.StatusCode, res.Header.Get("ETag"))
+	}
+	newTag := res.Header.Get("ETag")
+	for _, s := range []struct{ method, path, body string }{
+		{"POST", "/a", "[4]"},
+		{"PUT", "/a", "[4]"},
+		{"PUT", "/a/0", "4"},
+		{"DELETE", "/a/0", ""},
+		{"PUT", "/a/serialized", ""},
+		{"DELETE", "/a", ""},
+	} {
+		if res := do(t, h, s.method, s.path, s.body, "If-Match", tag); res.StatusCode != 412 {
+			t.Errorf("Conditional Test failed : %s %s want 412 got %d", s.method, s.path, res.StatusCode)
+		}
+	}
+	if got := text(t, do(t, h, "GET", "/a", "")); got != "[1,2,3]" {
+		t.Errorf("Conditional Test failed : want [1,2,3] got %s", got)
+	}
+
+	if res := do(t, h, "PUT", "/a/0", "7", "If-Match", `"x", `+newTag); res.StatusCode != 200 {
+		t.Errorf("Conditional Test failed : want 200 for a matching tag in a list got %d", res.StatusCode)
+	}
+	if res := do(t, h, "POST", "/a", "[1]", "If-Match", "*"); res.StatusCode != 200 {
+		t.Errorf("Conditional Test failed : want 200 for * got %d", res.StatusCode)
+	}
+	//* does not match a vector that does not exist
+	if res := do(t, h, "PUT", "/new", "[1]", "If-Match", "*"); res.StatusCode != 412 {
+		t.Errorf("Conditional Test failed : want 412 got %d", res.StatusCode)
+	}
+}
+
+func TestServer(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("/vectors/", http.StripPrefix("/vectors", NewHandler()))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	req, _ := http.NewRequest("PUT", srv.URL+"/vectors/a", strings.NewReader("[1,2,3]"))
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != 201 {
+		t.Fatalf("Server Test failed : want 201 got %d", res.StatusCode)
+	}
+	res, err = http.Get(srv.URL + "/vectors/a/2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := text(t, res); got != "3" {
+		t.Errorf("Server Test failed : want 3 got %s", got)
+	}
+	res.Body.Close()
+}
+
+func TestSlowUpload(t *testing.T) {
+	h := NewHandler()
+	do(t, h, "PUT", "/a", "[1,2,3]")
+
+	//an upload that has not sent its whole body yet must not hold up other requests
+	pr, pw := io.Pipe()
+	done := make(chan int)
+	go func() {
+		r := httptest.NewRequest("PUT", "/b", pr)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		done <- w.Code
+	}()
+	pw.Write([]byte("[4,"))
+
+	got := make(chan string)
+	go func() {
+		got <- text(t, do(t, h, "GET", "/a", ""))
+	}()
+	select {
+	case s := <-got:
+		if s != "[1,2,3]" {
+			t.Errorf("SlowUpload Test failed : want [1,2,3] got %s", s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("SlowUpload Test failed : GET is blocked by the upload")
+	}
+
+	pw.Write([]byte("5]"))
+	pw.Close()
+	if code := <-done; code != 201 {
+		t.Errorf("SlowUpload Test failed : want 201 got %d", code)
+	}
+	if s := text(t, do(t, h, "GET", "/b", "")); s != "[4,5]" {
+		t.Errorf("SlowUpload Test failed : want [4,5] got %s", s)
+	}
+}
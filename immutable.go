@@ -0,0 +1,430 @@
+package intvector
+
+import (
+	"encoding/hex"
+	"errors"
+	"sort"
+	"strconv"
+)
+
+const (
+	immBits  = 5
+	immWidth = 1 << immBits
+	immMask  = immWidth - 1
+)
+
+//immNode is a node of the trie, inner nodes have children and leaves have exactly immWidth vals
+type immNode struct {
+	children []*immNode
+	vals     []int
+}
+
+//ImmutableIntvector is a persistent vector, Push, Set, Pop and Concat return a new version and leave the old one valid.
+//Versions share all the parts they have in common, so every operation only copies O(log32 n) nodes.
+//It is laid out as a 32-way trie of full leaves plus a tail of up to 32 elements that Push and Pop work on directly.
+//Nothing is ever changed after it has been created, so every version can be used from any number of goroutines
+type ImmutableIntvector struct {
+	size  int
+	shift uint
+	root  *immNode
+	tail  []int
+}
+
+//emptyImmutable is the shared empty version
+var emptyImmutable = &ImmutableIntvector{shift: immBits, root: &immNode{}}
+
+//NewImmutableIntvector returns an immutable copy of the vector, it is built bottom up in O(n)
+func NewImmutableIntvector(v *Intvector) *ImmutableIntvector {
+	n := len(v.vec)
+	if n == 0 {
+		return emptyImmutable
+	}
+
+	im := &ImmutableIntvector{size: n, shift: immBits}
+	tailOffset := im.tailOffset()
+	im.tail = append([]int{}, v.vec[tailOffset:]...)
+
+	nodes := []*immNode{}
+	for i := 0; i < tailOffset; i += immWidth {
+		nodes = append(nodes, &immNode{vals: append([]int{}, v.vec[i:i+immWidth]...)})
+	}
+	for len(nodes) > immWidth {
+		parents := []*immNode{}
+		for i := 0; i < len(nodes); i += immWidth {
+			end := i + immWidth
+			if end > len(nodes) {
+				end = len(nodes)
+			}
+			parents = append(parents, &immNode{children: nodes[i:end:end]})
+		}
+		nodes = parents
+		im.shift += immBits
+	}
+	im.root = &immNode{children: nodes}
+	return im
+}
+
+//Intvector returns a mutable copy of the vector
+func (im *ImmutableIntvector) Intvector() *Intvector {
+	v := &Intvector{vec: make([]int, 0, im.size)}
+	im.each(func(s []int) {
+		v.vec = append(v.vec, s...)
+	})
+	return v
+}
+
+//tailOffset returns the index of the first element in the tail
+func (im *ImmutableIntvector) tailOffset() int {
+	if im.size < immWidth {
+		return 0
+	}
+	return ((im.size - 1) >> immBits) << immBits
+}
+
+//leafFor returns the leaf or the tail holding the element at i
+func (im *ImmutableIntvector) leafFor(i int) []int {
+	if i >= im.tailOffset() {
+		return im.tail
+	}
+	node := im.root
+	for level := im.shift; level > 0; level -= immBits {
+		node = node.children[(i>>level)&immMask]
+	}
+	return node.vals
+}
+
+//each calls f with every leaf in order and then with the tail
+func (im *ImmutableIntvector) each(f func(s []int)) {
+	for i := 0; i < im.tailOffset(); i += immWidth {
+		f(im.leafFor(i))
+	}
+	f(im.tail)
+}
+
+//Size returns the current size of the vector
+func (im *ImmutableIntvector) Size() int {
+	return im.size
+}
+
+//IsEmpty returns true if the vector is empty, false otherwise
+func (im *ImmutableIntvector) IsEmpty() bool {
+	return im.size == 0
+}
+
+//At allows for accesing any element of the vector
+func (im *ImmutableIntvector) At(i int) (int, error) {
+	if i >= im.size || i < 0 {
+		return 0, errors.New("Index out of bounds")
+	}
+	return im.leafFor(i)[i&immMask], nil
+}
+
+//First returns the first element of the vector
+func (im *ImmutableIntvector) First() (int, error) {
+	if im.size > 0 {
+		return im.At(0)
+	}
+	return 0, errors.New("Empty Vector")
+}
+
+//Last returns the last element of the vector
+func (im *ImmutableIntvector) Last() (int, error) {
+	if im.size > 0 {
+		return im.tail[len(im.tail)-1], nil
+	}
+	return 0, errors.New("Empty Vector")
+}
+
+//Push returns a new version with s added at the back
+func (im *ImmutableIntvector) Push(s int) *ImmutableIntvector {
+	//room in the tail
+	if im.size-im.tailOffset() < immWidth {
+		tail := make([]int, len(im.tail)+1)
+		copy(tail, im.tail)
+		tail[len(im.tail)] = s
+		return &ImmutableIntvector{size: im.size + 1, shift: im.shift, root: im.root, tail: tail}
+	}
+
+	//the full tail moves into the trie as a new leaf
+	leaf := &immNode{vals: im.tail}
+	var root *immNode
+	shift := im.shift
+	if (im.size >> immBits) > (1 << im.shift) {
+		//the trie is full, it gets a new root one level higher
+		root = &immNode{children: []*immNode{im.root, newImmPath(im.shift, leaf)}}
+		shift += immBits
+	} else {
+		root = im.pushLeaf(im.shift, im.root, leaf)
+	}
+	return &ImmutableIntvector{size: im.size + 1, shift: shift, root: root, tail: []int{s}}
+}
+
+//pushLeaf returns a copy of the path from parent down to where the leaf goes, with the leaf added
+func (im *ImmutableIntvector) pushLeaf(level uint, parent *immNode, leaf *immNode) *immNode {
+	subidx := ((im.size - 1) >> level) & immMask
+	node := &immNode{children: make([]*immNode, len(parent.children), subidx+1)}
+	copy(node.children, parent.children)
+
+	var child *immNode
+	if level == immBits {
+		child = leaf
+	} else if subidx < len(parent.children) {
+		child = im.pushLeaf(level-immBits, parent.children[subidx], leaf)
+	} else {
+		child = newImmPath(level-immBits, leaf)
+	}
+
+	if subidx < len(node.children) {
+		node.children[subidx] = child
+	} else {
+		node.children = append(node.children, child)
+	}
+	return node
+}
+
+//newImmPath returns a chain of single child nodes from level down to the leaf
+func newImmPath(level uint, leaf *immNode) *immNode {
+	if level == 0 {
+		return leaf
+	}
+	return &immNode{children: []*immNode{newImmPath(level-immBits, leaf)}}
+}
+
+//Set returns a new version with the element at idx changed to value
+func (im *ImmutableIntvector) Set(idx int, value int) (*ImmutableIntvector, error) {
+	if idx < 0 || idx >= im.size {
+		return nil, errors.New("idx out of range for vector of length " + strconv.Itoa(im.size))
+	}
+
+	next := *im
+	if idx >= im.tailOffset() {
+		next.tail = append([]int{}, im.tail...)
+		next.tail[idx&immMask] = value
+	} else {
+		next.root = setImm(im.shift, im.root, idx, value)
+	}
+	return &next, nil
+}
+
+//setImm returns a copy of the path from node down to the element at idx, with the element changed
+func setImm(level uint, node *immNode, idx int, value int) *immNode {
+	if level == 0 {
+		leaf := &immNode{vals: append([]int{}, node.vals...)}
+		leaf.vals[idx&immMask] = value
+		return leaf
+	}
+	copied := &immNode{children: append([]*immNode{}, node.children...)}
+	subidx := (idx >> level) & immMask
+	copied.children[subidx] = setImm(level-immBits, node.children[subidx], idx, value)
+	return copied
+}
+
+//Pop returns a new version without the last element, and that element
+func (im *ImmutableIntvector) Pop() (*ImmutableIntvector, int, error) {
+	if im.size == 0 {
+		return nil, 0, errors.New("Empty Vector")
+	}
+	last := im.tail[len(im.tail)-1]
+	if im.size == 1 {
+		return emptyImmutable, last, nil
+	}
+
+	//the tail keeps at least one element
+	if len(im.tail) > 1 {
+		tail := im.tail[: len(im.tail)-1 : len(im.tail)-1]
+		return &ImmutableIntvector{size: im.size - 1, shift: im.shift, root: im.root, tail: tail}, last, nil
+	}
+
+	//the last leaf of the trie becomes the new tail
+	tail := im.leafFor(im.size - 2)
+	root := im.popLeaf(im.shift, im.root)
+	shift := im.shift
+	if root == nil {
+		root = &immNode{}
+	}
+	if shift > immBits && len(root.children) == 1 {
+		root = root.children[0]
+		shift -= immBits
+	}
+	return &ImmutableIntvector{size: im.size - 1, shift: shift, root: root, tail: tail}, last, nil
+}
+
+//popLeaf returns a copy of node without its last leaf, or nil if nothing is left
+func (im *ImmutableIntvector) popLeaf(level uint, node *immNode) *immNode {
+	subidx := ((im.size - 2) >> level) & immMask
+	if level > immBits {
+		child := im.popLeaf(level-immBits, node.children[subidx])
+		if child == nil && subidx == 0 {
+			return nil
+		}
+		copied := &immNode{children: append([]*immNode{}, node.children[:subidx]...)}
+		if child != nil {
+			copied.children = append(copied.children, child)
+		}
+		return copied
+	}
+	if subidx == 0 {
+		return nil
+	}
+	return &immNode{children: append([]*immNode{}, node.children[:subidx]...)}
+}
+
+//Concat returns a new version with all the elements of other added at the back
+//The elements are pushed one by one, so it costs O(m log32 n) for m elements in other
+func (im *ImmutableIntvector) Concat(other *ImmutableIntvector) *ImmutableIntvector {
+	res := im
+	other.each(func(s []int) {
+		for _, val := range s {
+			res = res.Push(val)
+		}
+	})
+	return res
+}
+
+//Search function is used to search an element in the vector
+//linear search is performed and the index is returned with the first occurance of an element
+func (im *ImmutableIntvector) Search(n int) int {
+	idx, found := 0, -1
+	im.each(func(s []int) {
+		for _, val := range s {
+			if found < 0 && val == n {
+				found = idx
+			}
+			idx++
+		}
+	})
+	return found
+}
+
+//SearchAll function is used to search all the ocurrances of the given element in the vector
+func (im *ImmutableIntvector) SearchAll(n int) []int {
+	res := make([]int, 0)
+	idx := 0
+	im.each(func(s []int) {
+		for _, val := range s {
+			if val == n {
+				res = append(res, idx)
+			}
+			idx++
+		}
+	})
+	return res
+}
+
+//CountInstancesOf can be used to count the number of times an element occurs in the vector
+func (im *ImmutableIntvector) CountInstancesOf(num int) int {
+	return len(im.SearchAll(num))
+}
+
+//Min returns the minimum value and the corresponding index
+func (im *ImmutableIntvector) Min() (int, int) {
+	if im.size == 0 {
+		return 0, -1
+	}
+	min, minIdx, idx := im.tail[0], -1, 0
+	im.each(func(s []int) {
+		for _, val := range s {
+			if minIdx < 0 || val < min {
+				min, minIdx = val, idx
+			}
+			idx++
+		}
+	})
+	return min, minIdx
+}
+
+//Max returns the maximum value and the corresponding index
+func (im *ImmutableIntvector) Max() (int, int) {
+	if im.size == 0 {
+		return 0, -1
+	}
+	max, maxIdx, idx := im.tail[0], -1, 0
+	im.each(func(s []int) {
+		for _, val := range s {
+			if maxIdx < 0 || val > max {
+				max, maxIdx = val, idx
+			}
+			idx++
+		}
+	})
+	return max, maxIdx
+}
+
+//Average returns the average value of the entire vector
+func (im *ImmutableIntvector) Average() float64 {
+	if im.size == 0 {
+		return 0.0
+	}
+	sum := 0
+	im.each(func(s []int) {
+		for _, val := range s {
+			sum += val
+		}
+	})
+	return float64(sum) / float64(im.size)
+}
+
+//Mean returns the mean value of the entire vector - alias for average
+func (im *ImmutableIntvector) Mean() float64 {
+	return im.Average()
+}
+
+//Median returns the median of the entire vector
+func (im *ImmutableIntvector) Median() float64 {
+	return im.Intvector().Median()
+}
+
+//IsSorted returns true if the vector is sorted
+func (im *ImmutableIntvector) IsSorted() bool {
+	sorted := true
+	im.each(func(s []int) {
+		sorted = sorted && sort.IntsAreSorted(s)
+	})
+	if !sorted {
+		return false
+	}
+	//the leaves are sorted on their own, the borders between them still need to be checked
+	for i := immWidth; i < im.size; i += immWidth {
+		a, _ := im.At(i - 1)
+		b, _ := im.At(i)
+		if b < a {
+			return false
+		}
+	}
+	return true
+}
+
+//Frequency returns the frequency of each element as a key value map where key being the element and value being the occurance count
+func (im *ImmutableIntvector) Frequency() map[int]int {
+	m := make(map[int]int)
+	im.each(func(s []int) {
+		for _, val := range s {
+			m[val]++
+		}
+	})
+	return m
+}
+
+//Serialized returns the vector of integers as a slice of bytes
+func (im *ImmutableIntvector) Serialized() []byte {
+	b := make([]byte, 0, 8*im.size)
+	im.each(func(s []int) {
+		b = appendWords(b, s)
+	})
+	return b
+}
+
+//Hash returns the sha256 hash of the serialized version of the vector
+func (im *ImmutableIntvector) Hash() string {
+	return im.HashWith(HashSHA256)
+}
+
+//HashWith returns the hash of the serialized version of the vector using the given algorithm
+func (im *ImmutableIntvector) HashWith(f HashFunc) string {
+	h := f()
+	buf := make([]byte, 0, 8*immWidth)
+	im.each(func(s []int) {
+		h.Write(appendWords(buf[:0], s))
+	})
+	return hex.EncodeToString(h.Sum(nil))
+}
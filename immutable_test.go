@@ -0,0 +1,194 @@
+package intvector
+
+import (
+	"math/rand"
+	"sync"
+	"testing"
+)
+
+//checkImmutable compares every element and the size of im with want
+func checkImmutable(t *testing.T, name string, im *ImmutableIntvector, want []int) {
+	t.Helper()
+	if im.Size() != len(want) {
+		t.Fatalf("%s Test failed : want size %d got %d", name, len(want), im.Size())
+	}
+	for i, w := range want {
+		if got, err := im.At(i); err != nil || got != w {
+			t.Fatalf("%s Test failed : want %d at index %d got %d", name, w, i, got)
+		}
+	}
+}
+
+func TestImmutablePushPop(t *testing.T) {
+	//enough elements for a trie three levels deep
+	n := 40000
+	im := NewImmutableIntvector(&Intvector{})
+	versions := []*ImmutableIntvector{im}
+	want := []int{}
+	for i := 0; i < n; i++ {
+		im = im.Push(i * 3)
+		want = append(want, i*3)
+		versions = append(versions, im)
+	}
+	checkImmutable(t, "ImmutablePushPop", im, want)
+
+	//every old version still holds its own prefix
+	for _, k := range []int{0, 1, 31, 32, 33, 1024, 1056, 32*32*32 + 1} {
+		checkImmutable(t, "ImmutablePushPop", versions[k], want[:k])
+	}
+
+	for i := n - 1; i >= 0; i-- {
+		var got int
+		var err error
+		im, got, err = im.Pop()
+		if err != nil || got != want[i] {
+			t.Fatalf("ImmutablePushPop Test failed : want %d got %d", want[i], got)
+		}
+		if im.Size() != i {
+			t.Fatalf("ImmutablePushPop Test failed : want size %d got %d", i, im.Size())
+		}
+		if i%977 == 0 {
+			checkImmutable(t, "ImmutablePushPop", im, want[:i])
+		}
+	}
+	if _, _, err := im.Pop(); err == nil {
+		t.Error("ImmutablePushPop Test failed : want an error popping an empty vector")
+	}
+	checkImmutable(t, "ImmutablePushPop", versions[n], want)
+}
+
+func TestImmutableSet(t *testing.T) {
+	var v Intvector
+	for i := 0; i < 5000; i++ {
+		v.Push(rand.Intn(100))
+	}
+	im := NewImmutableIntvector(&v)
+	want := append([]int{}, v.vec...)
+
+	next := im
+	for i := 0; i < 500; i++ {
+		idx, val := rand.Intn(len(want)), rand.Intn(100)
+		next, _ = next.Set(idx, val)
+		v.Set(idx, val)
+	}
+	checkImmutable(t, "ImmutableSet", im, want)
+	checkImmutable(t, "ImmutableSet", next, v.vec)
+
+	if _, err := im.Set(len(want), 1); err == nil {
+		t.Error("ImmutableSet Test failed : want an error for an index out of range")
+	}
+}
+
+func TestImmutableConcat(t *testing.T) {
+	var a, b Intvector
+	for i := 0; i < 100; i++ {
+		a.Push(i)
+	}
+	for i := 0; i < 1000; i++ {
+		b.Push(-i)
+	}
+	ia, ib := NewImmutableIntvector(&a), NewImmutableIntvector(&b)
+	c := ia.Concat(ib)
+
+	checkImmutable(t, "ImmutableConcat", c, append(append([]int{}, a.vec...), b.vec...))
+	checkImmutable(t, "ImmutableConcat", ia, a.vec)
+	checkImmutable(t, "ImmutableConcat", ib, b.vec)
+}
+
+func TestImmutableConversion(t *testing.T) {
+	for _, n := range []int{0, 1, 32, 33, 1024, 1025, 1056, 1057, 33000} {
+		var v Intvector
+		for i := 0; i < n; i++ {
+			v.Push(rand.Int() - rand.Int())
+		}
+		im := NewImmutableIntvector(&v)
+		checkImmutable(t, "ImmutableConversion", im, v.vec)
+		if im.Hash() != v.Hash() {
+			t.Errorf("ImmutableConversion Test failed : hash differs for %d elements", n)
+		}
+
+		//a vector built in bulk must keep working with Push and Pop
+		grown := im.Push(7).Push(8)
+		back := grown.Intvector()
+		if back.Size() != n+2 {
+			t.Fatalf("ImmutableConversion Test failed : want size %d got %d", n+2, back.Size())
+		}
+		shrunk, _, _ := grown.Pop()
+		shrunk, _, _ = shrunk.Pop()
+		if shrunk.Hash() != v.Hash() {
+			t.Errorf("ImmutableConversion Test failed : Push and Pop did not round trip for %d elements", n)
+		}
+	}
+}
+
+func TestImmutableReadAPI(t *testing.T) {
+	var v Intvector
+	for i := 0; i < 777; i++ {
+		v.Push(rand.Intn(50) - 25)
+	}
+	im := NewImmutableIntvector(&v)
+
+	first, _ := im.First()
+	wantFirst, _ := v.First()
+	last, _ := im.Last()
+	wantLast, _ := v.Last()
+	if first != wantFirst || last != wantLast {
+		t.Errorf("ImmutableReadAPI Test failed : want first and last %d %d got %d %d", wantFirst, wantLast, first, last)
+	}
+	if im.Search(7) != v.Search(7) || im.CountInstancesOf(7) != v.CountInstancesOf(7) {
+		t.Error("ImmutableReadAPI Test failed : Search or CountInstancesOf differ")
+	}
+	min, minIdx := im.Min()
+	wantMin, wantMinIdx := v.Min()
+	max, maxIdx := im.Max()
+	wantMax, wantMaxIdx := v.Max()
+	if min != wantMin || minIdx != wantMinIdx || max != wantMax || maxIdx != wantMaxIdx {
+		t.Errorf("ImmutableReadAPI Test failed : want min %d@%d max %d@%d got %d@%d %d@%d", wantMin, wantMinIdx, wantMax, wantMaxIdx, min, minIdx, max, maxIdx)
+	}
+	if im.Average() != v.Average() || im.Median() != v.Median() {
+		t.Error("ImmutableReadAPI Test failed : Average or Median differ")
+	}
+	if im.HashWith(HashFNV) != v.HashWith(HashFNV) {
+		t.Error("ImmutableReadAPI Test failed : HashWith differs")
+	}
+	if len(im.Frequency()) != len(v.Frequency()) {
+		t.Error("ImmutableReadAPI Test failed : Frequency differs")
+	}
+
+	if im.IsSorted() {
+		t.Error("ImmutableReadAPI Test failed : want an unsorted vector")
+	}
+	v.Sort()
+	if !NewImmutableIntvector(&v).IsSorted() {
+		t.Error("ImmutableReadAPI Test failed : want a sorted vector")
+	}
+}
+
+func TestImmutableConcurrent(t *testing.T) {
+	var v Intvector
+	for i := 0; i < 2000; i++ {
+		v.Push(i)
+	}
+	base := NewImmutableIntvector(&v)
+	hash := base.Hash()
+
+	//every goroutine derives its own versions from the shared one, run with -race to check for data races
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			im := base
+			for i := 0; i < 500; i++ {
+				im = im.Push(g)
+				im, _ = im.Set(i, g)
+				im, _, _ = im.Pop()
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	if base.Hash() != hash {
+		t.Error("ImmutableConcurrent Test failed : the shared version changed")
+	}
+}
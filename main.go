@@ -2,7 +2,6 @@ package intvector
 
 import (
 	"crypto/sha256"
-	"encoding/binary"
 	"encoding/hex"
 	"errors"
 	"runtime"
@@ -12,17 +11,33 @@ import (
 
 //Intvector is a vector implementation in golang
 type Intvector struct {
-	vec []int
+	vec       []int
+	setHooks  []func(idx int, value int)
+	hashState *incrementalHash
+	//shared is true while the backing array may be used by a Clone or Snapshot, see own
+	shared    bool
+	history   *history
+	observers []*Subscription
 }
 
 //Push inserts/pushes a new integer at the back of the int slice
 func (v *Intvector) Push(s int) {
+	v.own()
 	v.vec = append(v.vec, s)
+	v.hashState.appended(s)
+	if v.recording() {
+		v.record(edit{kind: editInsert, idx: len(v.vec) - 1, vals: []int{s}})
+	}
 }
 
 //Insert appends a new slice to an existing slice
 func (v *Intvector) Insert(s ...int) {
+	v.own()
 	v.vec = append(v.vec, s...)
+	v.hashState.appended(s...)
+	if v.recording() && len(s) > 0 {
+		v.record(edit{kind: editInsert, idx: len(v.vec) - len(s), vals: append([]int{}, s...)})
+	}
 }
 
 //Pop removes the last element from the slice and retruns it
@@ -32,6 +47,10 @@ func (v *Intvector) Pop() (int, error) {
 	if len(v.vec) > 0 {
 		s = v.vec[len(v.vec)-1]
 		v.vec = v.vec[:len(v.vec)-1]
+		v.hashState.invalidate()
+		if v.recording() {
+			v.record(edit{kind: editRemove, idx: len(v.vec), vals: []int{s}})
+		}
 	} else {
 		//add better handling here
 		return 0, errors.New("Empty Vector")
@@ -46,6 +65,10 @@ func (v *Intvector) Shift() (int, error) {
 	if len(v.vec) > 0 {
 		s = v.vec[0]
 		v.vec = v.vec[1:len(v.vec)]
+		v.hashState.invalidate()
+		if v.recording() {
+			v.record(edit{kind: editRemove, idx: 0, vals: []int{s}})
+		}
 	} else {
 		//add better handling here
 		return 0, errors.New("Empty Vector")
@@ -56,6 +79,11 @@ func (v *Intvector) Shift() (int, error) {
 //Unshift inserts a new integer in the front of the slice
 func (v *Intvector) Unshift(s int) {
 	v.vec = append([]int{s}, v.vec...)
+	v.shared = false
+	v.hashState.invalidate()
+	if v.recording() {
+		v.record(edit{kind: editInsert, idx: 0, vals: []int{s}})
+	}
 }
 
 //RemoveAt removes the element at the given idx
@@ -64,7 +92,13 @@ func (v *Intvector) RemoveAt(idx int) error {
 		return errors.New("Index out of bounds")
 	}
 
+	v.own()
+	val := v.vec[idx]
 	v.vec = append(v.vec[:idx], v.vec[idx+1:]...)
+	v.hashState.invalidate()
+	if v.recording() {
+		v.record(edit{kind: editRemove, idx: idx, vals: []int{val}})
+	}
 	return nil
 }
 
@@ -81,13 +115,17 @@ func (v *Intvector) RemoveFirstOf(num int) bool {
 	}
 
 	if isFound {
+		v.own()
 		//better error handling here
 		if idx == len(v.vec) {
 			v.vec = v.vec[:idx]
 		} else {
 			v.vec = append(v.vec[:idx], v.vec[idx+1:]...)
 		}
-
+		v.hashState.invalidate()
+		if v.recording() {
+			v.record(edit{kind: editRemove, idx: idx, vals: []int{num}})
+		}
 	}
 	return isFound
 }
@@ -95,6 +133,11 @@ func (v *Intvector) RemoveFirstOf(num int) bool {
 //RemoveAll removes all instances of the given number and returns the total count of the number removed
 func (v *Intvector) RemoveAll(num int) int {
 	count := 0
+	before := v.vec
+	if v.recording() {
+		before = append([]int{}, v.vec...)
+	}
+	v.own()
 
 	for i := 0; i < len(v.vec); i++ {
 		val := v.vec[i]
@@ -108,6 +151,12 @@ func (v *Intvector) RemoveAll(num int) int {
 			count++
 		}
 	}
+	if count > 0 {
+		v.hashState.invalidate()
+		if v.recording() {
+			v.record(edit{kind: editReplace, before: before, vals: append([]int{}, v.vec...)})
+		}
+	}
 	return count
 }
 
@@ -127,7 +176,9 @@ func (v *Intvector) MakeUnique() {
 		}
 
 	}
-	v.vec = tmpVec
+	if len(tmpVec) != len(v.vec) {
+		v.replace(tmpVec)
+	}
 }
 
 //Size returns the current size of the vector
@@ -137,15 +188,22 @@ func (v *Intvector) Size() int {
 
 //Clear clears out the slice and invokes the garbage collector to reclaim the freed memory.
 func (v *Intvector) Clear() {
-	v.vec = nil
+	if len(v.vec) > 0 {
+		v.replace(nil)
+	}
 	runtime.GC()
 }
 
 //Reverse function can be used to reverse the vector
 func (v *Intvector) Reverse() {
+	v.own()
 	for i := 0; i < len(v.vec)/2; i++ {
 		v.vec[i], v.vec[len(v.vec)-1-i] = v.vec[len(v.vec)-i-1], v.vec[i]
 	}
+	v.hashState.invalidate()
+	if v.recording() {
+		v.record(edit{kind: editReverse})
+	}
 }
 
 //At allows for accesing any element of the vector
@@ -173,7 +231,12 @@ func (v *Intvector) Swap(idx1 int, idx2 int) error {
 		return errors.New("idx2 out of range for vector of length " + strconv.Itoa(len(v.vec)))
 	}
 
+	v.own()
 	v.vec[idx1], v.vec[idx2] = v.vec[idx2], v.vec[idx1]
+	v.hashState.invalidate()
+	if v.recording() {
+		v.record(edit{kind: editSwap, idx: idx1, idx2: idx2})
+	}
 
 	return nil
 }
@@ -189,13 +252,32 @@ func (v *Intvector) Set(idx int, value int) error {
 		return errors.New("idx out of range for vector of length " + strconv.Itoa(len(v.vec)))
 	}
 
+	v.own()
+	old := v.vec[idx]
 	v.vec[idx] = value
+	v.hashState.invalidate()
+	if v.recording() {
+		v.record(edit{kind: editSet, idx: idx, old: old, new: value})
+	}
+	for _, f := range v.setHooks {
+		f(idx, value)
+	}
 	return nil
 }
 
+//OnSet registers f to be called after every successful call to Set with the same idx and value.
+//This can be used to keep derived structures such as a SegmentTree consistent with the vector
+func (v *Intvector) OnSet(f func(idx int, value int)) {
+	v.setHooks = append(v.setHooks, f)
+}
+
 //SortedPush pushes the incoming element into the vector in a sorted way
 //it is assumed that the Vector is already sorted
 func (v *Intvector) SortedPush(n int) {
+	v.own()
+	v.hashState.invalidate()
+	//at is the index the element ends up at
+	at := 0
 	if len(v.vec) == 0 {
 		v.vec = append(v.vec, n)
 	} else if len(v.vec) == 1 {
@@ -203,11 +285,13 @@ func (v *Intvector) SortedPush(n int) {
 			v.vec = append([]int{n}, v.vec...)
 		} else {
 			v.vec = append(v.vec, n)
+			at = 1
 		}
 	} else if n <= v.vec[0] {
 		v.vec = append([]int{n}, v.vec...)
 	} else if n >= v.vec[len(v.vec)-1] {
 		v.vec = append(v.vec, n)
+		at = len(v.vec) - 1
 	} else {
 		//use binary insertion here
 		l := 0
@@ -226,6 +310,10 @@ func (v *Intvector) SortedPush(n int) {
 		}
 		m = m + 1
 		v.vec = append(v.vec[:m], append([]int{n}, v.vec[m:]...)...)
+		at = m
+	}
+	if v.recording() {
+		v.record(edit{kind: editInsert, idx: at, vals: []int{n}})
 	}
 }
 
@@ -239,14 +327,28 @@ func (v *Intvector) UniquePush(n int) bool {
 			return isPushed
 		}
 	}
+	v.own()
 	v.vec = append(v.vec, n)
+	v.hashState.appended(n)
+	if v.recording() {
+		v.record(edit{kind: editInsert, idx: len(v.vec) - 1, vals: []int{n}})
+	}
 	isPushed = true
 	return isPushed
 }
 
 //Sort function sorts the vector
 func (v *Intvector) Sort() {
+	var before []int
+	if v.recording() {
+		before = append([]int{}, v.vec...)
+	}
+	v.own()
 	sort.Ints(v.vec)
+	v.hashState.invalidate()
+	if v.recording() {
+		v.record(edit{kind: editReorder, before: before, vals: append([]int{}, v.vec...)})
+	}
 }
 
 //IsSorted returns true if the vector is sorted
@@ -342,9 +444,18 @@ func (v *Intvector) Max() (int, int) {
 
 //ScaleBy scales the entire vector by the given scalefactor
 func (v *Intvector) ScaleBy(s int) {
+	var before []int
+	if v.recording() {
+		before = append([]int{}, v.vec...)
+	}
+	v.own()
 	for i, value := range v.vec {
 		v.vec[i] = s * value
 	}
+	v.hashState.invalidate()
+	if v.recording() {
+		v.record(edit{kind: editReplace, before: before, vals: append([]int{}, v.vec...)})
+	}
 }
 
 //Average returns the average value of the entire vector
@@ -488,17 +599,12 @@ func (v *Intvector) IsEmpty() bool {
 
 //Serialized returns the vector of integers as a slice of bytes
 func (v *Intvector) Serialized() []byte {
-	var b []byte
-	for i := 0; i < v.Size(); i++ {
-		bts := make([]byte, 8)
-		binary.BigEndian.PutUint64(bts, uint64(v.vec[i]))
-
-		b = append(b, bts...)
-	}
-	return b
+	//every element is written as an int64 so the result is the same on 32 bit and 64 bit platforms
+	return appendWords(make([]byte, 0, 8*len(v.vec)), v.vec)
 }
 
 //DeserializeFrom takes a byte array and parses into an int vector
+//On 32 bit platforms ErrOverflow is returned for values that do not fit into an int
 func (v *Intvector) DeserializeFrom(b []byte, append bool) error {
 
 	var err error
@@ -512,12 +618,19 @@ func (v *Intvector) DeserializeFrom(b []byte, append bool) error {
 		return err
 	}
 
-	if !append {
-		v.Clear()
+	//decode everything first so the vector is left untouched if a value overflows int
+	s := make([]int, len(b)/8)
+	for i := range s {
+		if s[i], err = decodeWord(b[8*i:]); err != nil {
+			return err
+		}
 	}
 
-	for i := 0; i < len(b); i = i + 8 {
-		v.Push(int(binary.BigEndian.Uint64(b[i : i+8])))
+	if !append {
+		//replace the contents in a single step so that it is a single entry in the history
+		v.replace(s)
+	} else {
+		v.Insert(s...)
 	}
 
 	return err
@@ -0,0 +1,391 @@
+//go:build linux
+
+package intvector
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"math/bits"
+	"os"
+	"sort"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+//ErrReadOnly is returned when a mutating method is called on a MappedIntvector opened read-only
+var ErrReadOnly = errors.New("Vector is read-only")
+
+//maxMappedWords is the largest number of elements whose size in bytes fits in an int
+const maxMappedWords = math.MaxInt / 8
+
+//MappedIntvector is a vector backed by a memory mapped file in the layout of Serialized.
+//Elements are read from and written to the page cache directly, so a vector larger than the memory
+//can be used without loading it and reopening it is instant
+//The file always has the exact layout of Serialized, even if the process crashes before Sync or Close.
+//Push grows the mapping in large steps beyond the end of the file and only the file itself by the pushed elements
+type MappedIntvector struct {
+	f *os.File
+	//data is the whole mapping, only its first 8*n bytes are backed by the file
+	data     []byte
+	n        int
+	writable bool
+}
+
+//OpenMapped maps the file at path, it is created if it does not exist and writable is true
+func OpenMapped(path string, writable bool) (*MappedIntvector, error) {
+	flag, prot := os.O_RDONLY, syscall.PROT_READ
+	if writable {
+		flag, prot = os.O_RDWR|os.O_CREATE, syscall.PROT_READ|syscall.PROT_WRITE
+	}
+
+	f, err := os.OpenFile(path, flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.Size()%8 != 0 {
+		f.Close()
+		return nil, errors.New("Invalid length")
+	}
+
+	m := &MappedIntvector{f: f, n: int(info.Size() / 8), writable: writable}
+	if info.Size() > 0 {
+		if m.data, err = syscall.Mmap(int(f.Fd()), 0, int(info.Size()), prot, syscall.MAP_SHARED); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+
+	//with a 32 bit int every element is checked once here, so that reading it later cannot overflow
+	if bits.UintSize == 32 {
+		for i := 0; i < m.n; i++ {
+			if _, err := m.word(i); err != nil {
+				m.Close()
+				return nil, err
+			}
+		}
+	}
+	return m, nil
+}
+
+//remap changes the size of the mapping to size bytes, the size of the file is left as it is.
+//The old mapping is only dropped once the new one exists, so the vector stays usable if mapping fails
+func (m *MappedIntvector) remap(size int) error {
+	data, err := syscall.Mmap(int(m.f.Fd()), 0, size, syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED)
+	if err != nil {
+		return err
+	}
+	if m.data != nil {
+		if err := syscall.Munmap(m.data); err != nil {
+			syscall.Munmap(data)
+			return err
+		}
+	}
+	m.data = data
+	return nil
+}
+
+//word returns the element at idx without bounds checks
+//It can only return ErrOverflow if another process wrote to the file after OpenMapped checked it
+func (m *MappedIntvector) word(idx int) (int, error) {
+	return decodeWord(m.data[8*idx:])
+}
+
+//value returns the element at idx for the methods that cannot return an error, without bounds checks
+//OpenMapped has checked that every element fits in an int, and Set and Insert only store ints
+func (m *MappedIntvector) value(idx int) int {
+	n, _ := m.word(idx)
+	return n
+}
+
+//Size returns the current size of the vector
+func (m *MappedIntvector) Size() int {
+	return m.n
+}
+
+//IsEmpty returns true if the vector is empty, false otherwise
+func (m *MappedIntvector) IsEmpty() bool {
+	return m.n == 0
+}
+
+//At allows for accesing any element of the vector
+func (m *MappedIntvector) At(i int) (int, error) {
+	if i >= m.n || i < 0 {
+		return 0, errors.New("Index out of bounds")
+	}
+	return m.word(i)
+}
+
+//First returns the first element of the vector
+func (m *MappedIntvector) First() (int, error) {
+	if m.n > 0 {
+		return m.At(0)
+	}
+	return 0, errors.New("Empty Vector")
+}
+
+//Last returns the last element of the vector
+func (m *MappedIntvector) Last() (int, error) {
+	if m.n > 0 {
+		return m.At(m.n - 1)
+	}
+	return 0, errors.New("Empty Vector")
+}
+
+//Set function can be used to set the value at a specific index in the vector
+func (m *MappedIntvector) Set(idx int, value int) error {
+	if !m.writable {
+		return ErrReadOnly
+	}
+	if idx < 0 {
+		return errors.New("idx must be a positive number")
+	}
+	if idx >= m.n {
+		return errors.New("idx out of range for vector of length " + strconv.Itoa(m.n))
+	}
+	appendWord(m.data[8*idx:8*idx], value)
+	return nil
+}
+
+//Push inserts/pushes a new integer at the back of the vector, growing the file if needed
+func (m *MappedIntvector) Push(s int) error {
+	return m.Insert(s)
+}
+
+//Insert appends the given integers to the back of the vector, growing the file if needed
+func (m *MappedIntvector) Insert(s ...int) error {
+	if !m.writable {
+		return ErrReadOnly
+	}
+
+	if len(s) > maxMappedWords-m.n {
+		return ErrOverflow
+	}
+	need := 8 * (m.n + len(s))
+	if need > len(m.data) {
+		//grow the mapping by doubling so that a series of Push calls only remaps O(log n) times,
+		//near the largest size the mapping grows to exactly what is needed instead
+		size := len(m.data)
+		if size < 4096 {
+			size = 4096
+		}
+		for size < need {
+			if size > math.MaxInt/2 {
+				size = need
+				break
+			}
+			size *= 2
+		}
+		if err := m.remap(size); err != nil {
+			return err
+		}
+	}
+	//the file only grows by the new elements, so it never holds padding that would be read back as zeros
+	if err := m.f.Truncate(int64(need)); err != nil {
+		return err
+	}
+
+	for _, val := range s {
+		appendWord(m.data[8*m.n:8*m.n], val)
+		m.n++
+	}
+	return nil
+}
+
+//Search function is used to search an element in the vector
+//linear search is performed and the index is returned with the first occurance of an element
+func (m *MappedIntvector) Search(n int) int {
+	for i := 0; i < m.n; i++ {
+		if m.value(i) == n {
+			return i
+		}
+	}
+	return -1
+}
+
+//SearchAll function is used to search all the ocurrances of the given element in the vector
+func (m *MappedIntvector) SearchAll(n int) []int {
+	s := make([]int, 0)
+	for i := 0; i < m.n; i++ {
+		if m.value(i) == n {
+			s = append(s, i)
+		}
+	}
+	return s
+}
+
+//CountInstancesOf can be used to count the number of times an element occurs in the vector
+func (m *MappedIntvector) CountInstancesOf(num int) int {
+	return len(m.SearchAll(num))
+}
+
+//Min returns the minimum value and the corresponding index
+func (m *MappedIntvector) Min() (int, int) {
+	if m.n == 0 {
+		return 0, -1
+	}
+	min, idx := m.value(0), 0
+	for i := 1; i < m.n; i++ {
+		if val := m.value(i); val < min {
+			min, idx = val, i
+		}
+	}
+	return min, idx
+}
+
+//Max returns the maximum value and the corresponding index
+func (m *MappedIntvector) Max() (int, int) {
+	if m.n == 0 {
+		return 0, -1
+	}
+	max, idx := m.value(0), 0
+	for i := 1; i < m.n; i++ {
+		if val := m.value(i); val > max {
+			max, idx = val, i
+		}
+	}
+	return max, idx
+}
+
+//Average returns the average value of the entire vector
+func (m *MappedIntvector) Average() float64 {
+	if m.n == 0 {
+		return 0.0
+	}
+	sum := 0
+	for i := 0; i < m.n; i++ {
+		sum += m.value(i)
+	}
+	return float64(sum) / float64(m.n)
+}
+
+//Mean returns the mean value of the entire vector - alias for average
+func (m *MappedIntvector) Mean() float64 {
+	return m.Average()
+}
+
+//IsSorted returns true if the vector is sorted
+func (m *MappedIntvector) IsSorted() bool {
+	for i := 1; i < m.n; i++ {
+		if m.value(i) < m.value(i-1) {
+			return false
+		}
+	}
+	return true
+}
+
+//Median returns the median of the entire vector, it needs a sorted copy of the whole vector in memory
+func (m *MappedIntvector) Median() float64 {
+	return m.Intvector().Median()
+}
+
+//Mode returns the most frequent element, or an error if there is no single one
+func (m *MappedIntvector) Mode() (int, error) {
+	modes, err := m.mostFrequent()
+	if err != nil {
+		return 0, err
+	}
+	if len(modes) > 1 {
+		return 0, errors.New("No unique mode available")
+	}
+	return modes[0], nil
+}
+
+//Modes returns the Modes of the vector in sorted order. This function is to be used for multimodal distribution.
+func (m *MappedIntvector) Modes() ([]int, error) {
+	modes, err := m.mostFrequent()
+	if err != nil {
+		return nil, err
+	}
+	if len(modes) == 1 {
+		return nil, errors.New("Unique mode")
+	}
+	return modes, nil
+}
+
+//mostFrequent returns the elements with the highest count in sorted order, it only keeps the counts in memory
+func (m *MappedIntvector) mostFrequent() ([]int, error) {
+	if m.n == 0 {
+		return nil, errors.New("Empty Vector")
+	}
+	max := 0
+	var modes []int
+	for val, count := range m.Frequency() {
+		if count > max {
+			max, modes = count, modes[:0]
+		}
+		if count == max {
+			modes = append(modes, val)
+		}
+	}
+	sort.Ints(modes)
+	return modes, nil
+}
+
+//Frequency returns the frequency of each element as a key value map where key being the element and value being the occurance count
+func (m *MappedIntvector) Frequency() map[int]int {
+	f := make(map[int]int)
+	for i := 0; i < m.n; i++ {
+		f[m.value(i)]++
+	}
+	return f
+}
+
+//Serialized returns a copy of the mapped bytes, which already are in the layout of Serialized
+func (m *MappedIntvector) Serialized() []byte {
+	return append([]byte{}, m.data[:8*m.n]...)
+}
+
+//Hash returns the sha256 hash of the serialized version of the vector without copying it
+func (m *MappedIntvector) Hash() string {
+	return m.HashWith(HashSHA256)
+}
+
+//HashWith returns the hash of the serialized version of the vector using the given algorithm
+func (m *MappedIntvector) HashWith(f HashFunc) string {
+	h := f()
+	h.Write(m.data[:8*m.n])
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+//Intvector loads a copy of the whole vector into memory
+func (m *MappedIntvector) Intvector() *Intvector {
+	var v Intvector
+	v.vec, _ = decodeWords(make([]int, 0, m.n), m.data[:8*m.n])
+	return &v
+}
+
+//Sync flushes the mapped pages to disk
+func (m *MappedIntvector) Sync() error {
+	if !m.writable {
+		return nil
+	}
+	if m.n > 0 {
+		_, _, errno := syscall.Syscall(syscall.SYS_MSYNC, uintptr(unsafe.Pointer(&m.data[0])), uintptr(8*m.n), syscall.MS_SYNC)
+		if errno != 0 {
+			return errno
+		}
+	}
+	return m.f.Sync()
+}
+
+//Close syncs a writable vector, unmaps it and closes the file
+func (m *MappedIntvector) Close() error {
+	err := m.Sync()
+	if m.data != nil {
+		if uerr := syscall.Munmap(m.data); err == nil {
+			err = uerr
+		}
+		m.data = nil
+	}
+	if cerr := m.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
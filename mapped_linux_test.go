@@ -0,0 +1,186 @@
+//go:build linux
+
+package intvector
+
+import (
+	"math/bits"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMappedIntvector(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vec.bin")
+
+	var s Intvector
+	s.Insert(5, -3, 8, 8, 1)
+	if err := os.WriteFile(path, s.Serialized(), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := OpenMapped(path, true)
+	if err != nil {
+		t.Fatalf("MappedIntvector Test failed : OpenMapped returned error %s", err)
+	}
+
+	if m.Size() != 5 {
+		t.Errorf("MappedIntvector Test failed : want size %d got %d", 5, m.Size())
+	}
+	if got, _ := m.At(1); got != -3 {
+		t.Errorf("MappedIntvector Test failed : want %d at index 1 got %d", -3, got)
+	}
+	if _, err := m.At(5); err == nil {
+		t.Error("MappedIntvector Test failed : At should return error for out of range index")
+	}
+	if min, idx := m.Min(); min != -3 || idx != 1 {
+		t.Errorf("MappedIntvector Test failed : want Min -3 at 1 got %d at %d", min, idx)
+	}
+	if max, idx := m.Max(); max != 8 || idx != 2 {
+		t.Errorf("MappedIntvector Test failed : want Max 8 at 2 got %d at %d", max, idx)
+	}
+	if got := m.Search(8); got != 2 {
+		t.Errorf("MappedIntvector Test failed : want Search index 2 got %d", got)
+	}
+	if got := m.CountInstancesOf(8); got != 2 {
+		t.Errorf("MappedIntvector Test failed : want count 2 got %d", got)
+	}
+	if got := m.Median(); got != s.Median() {
+		t.Errorf("MappedIntvector Test failed : want median %f got %f", s.Median(), got)
+	}
+	if m.Hash() != s.Hash() {
+		t.Error("MappedIntvector Test failed : hash differs from the in memory vector")
+	}
+	if mode, err := m.Mode(); err != nil || mode != 8 {
+		t.Errorf("MappedIntvector Test failed : want Mode 8 got %d, %v", mode, err)
+	}
+	if _, err := m.Modes(); err == nil {
+		t.Error("MappedIntvector Test failed : Modes should return error for a unique mode")
+	}
+
+	if err := m.Set(0, 42); err != nil {
+		t.Errorf("MappedIntvector Test failed : Set returned error %s", err)
+	}
+	s.Set(0, 42)
+	for i := 0; i < 10000; i++ {
+		if err := m.Push(i); err != nil {
+			t.Fatalf("MappedIntvector Test failed : Push returned error %s", err)
+		}
+		s.Push(i)
+	}
+	if m.Hash() != s.Hash() {
+		t.Error("MappedIntvector Test failed : hash differs from the in memory vector after Push")
+	}
+
+	//without Sync or Close the file already holds exactly the elements, as it would after a crash
+	if info, err := os.Stat(path); err != nil || info.Size() != int64(8*s.Size()) {
+		t.Errorf("MappedIntvector Test failed : want file size %d before Close got %v (err %v)", 8*s.Size(), info.Size(), err)
+	}
+	crashed, err := OpenMapped(path, false)
+	if err != nil || crashed.Size() != s.Size() || crashed.Hash() != s.Hash() {
+		t.Errorf("MappedIntvector Test failed : vector opened before Close differs (err %v)", err)
+	}
+	if crashed != nil {
+		crashed.Close()
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("MappedIntvector Test failed : Close returned error %s", err)
+	}
+
+	//after closing the file must be exactly the serialized vector
+	b, _ := os.ReadFile(path)
+	var loaded Intvector
+	if err := loaded.DeserializeFrom(b, false); err != nil || loaded.Hash() != s.Hash() {
+		t.Errorf("MappedIntvector Test failed : file does not hold the serialized vector (err %v)", err)
+	}
+
+	ro, err := OpenMapped(path, false)
+	if err != nil {
+		t.Fatalf("MappedIntvector Test failed : OpenMapped read-only returned error %s", err)
+	}
+	defer ro.Close()
+	if ro.Size() != s.Size() || ro.Hash() != s.Hash() {
+		t.Error("MappedIntvector Test failed : reopened vector differs")
+	}
+	if err := ro.Set(0, 1); err != ErrReadOnly {
+		t.Errorf("MappedIntvector Test failed : want ErrReadOnly for Set, got %v", err)
+	}
+	if err := ro.Push(1); err != ErrReadOnly {
+		t.Errorf("MappedIntvector Test failed : want ErrReadOnly for Push, got %v", err)
+	}
+}
+
+func TestOpenMapped(t *testing.T) {
+	dir := t.TempDir()
+
+	//a new writable vector starts out empty
+	m, err := OpenMapped(filepath.Join(dir, "new.bin"), true)
+	if err != nil {
+		t.Fatalf("OpenMapped Test failed : returned error %s", err)
+	}
+	if !m.IsEmpty() {
+		t.Error("OpenMapped Test failed : new vector is not empty")
+	}
+	if _, err := m.First(); err == nil {
+		t.Error("OpenMapped Test failed : First should return error for empty vector")
+	}
+	m.Close()
+
+	if _, err := OpenMapped(filepath.Join(dir, "missing.bin"), false); err == nil {
+		t.Error("OpenMapped Test failed : should return error for missing file opened read-only")
+	}
+
+	bad := filepath.Join(dir, "bad.bin")
+	os.WriteFile(bad, []byte{1, 2, 3}, 0644)
+	if _, err := OpenMapped(bad, false); err == nil {
+		t.Error("OpenMapped Test failed : should return error for invalid length")
+	}
+
+	//an element that does not fit in an int is reported when opening instead of being read as 0
+	wide := filepath.Join(dir, "wide.bin")
+	os.WriteFile(wide, appendUint64(nil, 1<<40), 0644)
+	m, err = OpenMapped(wide, false)
+	if bits.UintSize == 32 && err != ErrOverflow {
+		t.Errorf("OpenMapped Test failed : want ErrOverflow got %v", err)
+	}
+	if bits.UintSize == 64 {
+		if got, _ := m.At(0); err != nil || int64(got) != 1<<40 {
+			t.Errorf("OpenMapped Test failed : want %d got %d (err %v)", int64(1<<40), got, err)
+		}
+		m.Close()
+	}
+}
+
+func TestMappedIntvectorGrow(t *testing.T) {
+	m, err := OpenMapped(filepath.Join(t.TempDir(), "vec.bin"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	m.Insert(1, 1, 2, 2)
+	if modes, err := m.Modes(); err != nil || len(modes) != 2 || modes[0] != 1 || modes[1] != 2 {
+		t.Errorf("MappedIntvector Grow Test failed : want Modes [1 2] got %v, %v", modes, err)
+	}
+	if _, err := m.Mode(); err == nil {
+		t.Error("MappedIntvector Grow Test failed : Mode should return error without a unique mode")
+	}
+
+	//a size in bytes that does not fit in an int is refused before anything is mapped
+	n := m.n
+	m.n = maxMappedWords
+	if err := m.Push(1); err != ErrOverflow {
+		t.Errorf("MappedIntvector Grow Test failed : want ErrOverflow got %v", err)
+	}
+	m.n = n
+
+	//if the larger mapping cannot be made the old one is kept
+	f := m.f
+	m.f, _ = os.Open(os.DevNull)
+	m.f.Close()
+	if err := m.Insert(make([]int, 1000)...); err == nil {
+		t.Error("MappedIntvector Grow Test failed : want an error from a failed mapping")
+	}
+	m.f = f
+	if got, err := m.At(3); err != nil || got != 2 || m.Size() != 4 {
+		t.Errorf("MappedIntvector Grow Test failed : want the vector intact got %d, %v and size %d", got, err, m.Size())
+	}
+}
@@ -0,0 +1,226 @@
+package intvector
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+)
+
+//MerkleTree is a hash tree over fixed size chunks of a vector.
+//It lets the owner of a vector prove that a single element is part of it to anyone who knows the Root,
+//without sending the whole vector. A leaf is the hash of 0x00 followed by the serialized chunk
+//and an inner node is the hash of 0x01 followed by its two children, a node without a sibling is carried up unchanged
+type MerkleTree struct {
+	hash      HashFunc
+	chunkSize int
+	vec       []int
+	//levels[0] holds the leaves and the last level holds the root
+	levels [][][]byte
+}
+
+//MerkleProof shows that Chunk is the chunk holding the element at Index in a vector of Size elements
+type MerkleProof struct {
+	Index     int
+	Size      int
+	ChunkSize int
+	Chunk     []int
+	//Siblings are the hashes needed to go from the leaf up to the root, from the bottom up
+	Siblings [][]byte
+}
+
+//NewMerkleTree builds a merkle tree over a copy of the current elements of the vector, chunkSize elements per leaf
+func NewMerkleTree(v *Intvector, chunkSize int, f HashFunc) (*MerkleTree, error) {
+	if chunkSize <= 0 {
+		return nil, errors.New("chunkSize must be a positive number")
+	}
+
+	m := &MerkleTree{hash: f, chunkSize: chunkSize, vec: append([]int{}, v.vec...)}
+	leaves := [][]byte{}
+	for i := 0; i < len(m.vec); i += chunkSize {
+		leaves = append(leaves, merkleLeaf(f, m.chunk(i/chunkSize)))
+	}
+
+	m.levels = [][][]byte{leaves}
+	for level := leaves; len(level) > 1; {
+		next := make([][]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				next = append(next, level[i])
+			} else {
+				next = append(next, merkleNode(f, level[i], level[i+1]))
+			}
+		}
+		m.levels = append(m.levels, next)
+		level = next
+	}
+	return m, nil
+}
+
+//merkleLeaf hashes a chunk of elements
+func merkleLeaf(f HashFunc, chunk []int) []byte {
+	h := f()
+	h.Write([]byte{0})
+	h.Write(appendWords(nil, chunk))
+	return h.Sum(nil)
+}
+
+//merkleNode hashes two child nodes
+func merkleNode(f HashFunc, left []byte, right []byte) []byte {
+	h := f()
+	h.Write([]byte{1})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
+
+//chunk returns the elements of the c-th leaf
+func (m *MerkleTree) chunk(c int) []int {
+	start := c * m.chunkSize
+	end := start + m.chunkSize
+	if end > len(m.vec) {
+		end = len(m.vec)
+	}
+	return m.vec[start:end]
+}
+
+//Root returns the root hash of the tree, for an empty vector it is the hash of no data
+func (m *MerkleTree) Root() []byte {
+	top := m.levels[len(m.levels)-1]
+	if len(top) == 0 {
+		return m.hash().Sum(nil)
+	}
+	return top[0]
+}
+
+//Proof returns the membership proof for the element at idx, the element itself is Chunk[idx%ChunkSize]
+func (m *MerkleTree) Proof(idx int) (*MerkleProof, error) {
+	if idx < 0 || idx >= len(m.vec) {
+		return nil, errors.New("idx out of range for tree of size " + strconv.Itoa(len(m.vec)))
+	}
+
+	c := idx / m.chunkSize
+	p := &MerkleProof{
+		Index:     idx,
+		Size:      len(m.vec),
+		ChunkSize: m.chunkSize,
+		Chunk:     append([]int{}, m.chunk(c)...),
+	}
+	for _, level := range m.levels[:len(m.levels)-1] {
+		if c%2 == 1 {
+			p.Siblings = append(p.Siblings, level[c-1])
+		} else if c+1 < len(level) {
+			p.Siblings = append(p.Siblings, level[c+1])
+		}
+		c /= 2
+	}
+	return p, nil
+}
+
+//Value returns the element the proof is about
+func (p *MerkleProof) Value() (int, error) {
+	if p.ChunkSize <= 0 || p.Index < 0 {
+		return 0, errors.New("Invalid proof")
+	}
+	i := p.Index % p.ChunkSize
+	if i >= len(p.Chunk) {
+		return 0, errors.New("Invalid proof")
+	}
+	return p.Chunk[i], nil
+}
+
+//VerifyProof returns true if the proof leads from the element to the given root using the given algorithm
+//The position of every sibling is derived from Index and Size, so a proof can not be moved to another index
+func VerifyProof(root []byte, p *MerkleProof, f HashFunc) bool {
+	if p == nil || p.ChunkSize <= 0 || p.Index < 0 || p.Index >= p.Size {
+		return false
+	}
+
+	c := p.Index / p.ChunkSize
+	wantLen := p.Size - c*p.ChunkSize
+	if wantLen > p.ChunkSize {
+		wantLen = p.ChunkSize
+	}
+	if len(p.Chunk) != wantLen {
+		return false
+	}
+
+	node := merkleLeaf(f, p.Chunk)
+	siblings := p.Siblings
+	for count := (p.Size + p.ChunkSize - 1) / p.ChunkSize; count > 1; count = (count + 1) / 2 {
+		if c%2 == 1 || c+1 < count {
+			if len(siblings) == 0 {
+				return false
+			}
+			if c%2 == 1 {
+				node = merkleNode(f, siblings[0], node)
+			} else {
+				node = merkleNode(f, node, siblings[0])
+			}
+			siblings = siblings[1:]
+		}
+		c /= 2
+	}
+	return len(siblings) == 0 && bytes.Equal(node, root)
+}
+
+//Serialized returns the proof as a slice of bytes so it can be sent to a remote party
+//The layout is Index, Size, ChunkSize, the chunk length, the chunk, the number of siblings and then every sibling
+//prefixed by its length, all numbers as 8 byte big endian words
+func (p *MerkleProof) Serialized() []byte {
+	b := appendWord(nil, p.Index)
+	b = appendWord(b, p.Size)
+	b = appendWord(b, p.ChunkSize)
+	b = appendWord(b, len(p.Chunk))
+	b = appendWords(b, p.Chunk)
+	b = appendWord(b, len(p.Siblings))
+	for _, s := range p.Siblings {
+		b = appendWord(b, len(s))
+		b = append(b, s...)
+	}
+	return b
+}
+
+//DeserializeFrom replaces the proof with the one encoded in b by Serialized
+func (p *MerkleProof) DeserializeFrom(b []byte) error {
+	r := wordReader{b: b}
+	var tmp MerkleProof
+	var err error
+	for _, field := range []*int{&tmp.Index, &tmp.Size, &tmp.ChunkSize} {
+		if *field, err = r.word(); err != nil {
+			return err
+		}
+	}
+
+	n, err := r.word()
+	if err != nil {
+		return err
+	}
+	if tmp.Chunk, err = r.words(n); err != nil {
+		return err
+	}
+
+	n, err = r.word()
+	if err != nil {
+		return err
+	}
+	if n < 0 || n > len(r.b) {
+		return errors.New("Invalid length")
+	}
+	for i := 0; i < n; i++ {
+		l, err := r.word()
+		if err != nil {
+			return err
+		}
+		if l < 0 || l > len(r.b) {
+			return errors.New("Invalid length")
+		}
+		tmp.Siblings = append(tmp.Siblings, append([]byte{}, r.b[:l]...))
+		r.b = r.b[l:]
+	}
+	if !r.done() {
+		return errors.New("Invalid length")
+	}
+
+	*p = tmp
+	return nil
+}
@@ -0,0 +1,87 @@
+package intvector
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMerkleTree(t *testing.T) {
+	for _, size := range []int{1, 2, 5, 16, 17, 100} {
+		for _, chunkSize := range []int{1, 3, 8} {
+			var s Intvector
+			for i := 0; i < size; i++ {
+				s.Push(i*i - 50)
+			}
+			m, err := NewMerkleTree(&s, chunkSize, HashSHA256)
+			if err != nil {
+				t.Fatalf("MerkleTree Test failed : NewMerkleTree returned error %s", err)
+			}
+			root := m.Root()
+
+			for idx := 0; idx < size; idx++ {
+				p, err := m.Proof(idx)
+				if err != nil {
+					t.Errorf("MerkleTree Test failed : Proof returned error %s", err)
+					continue
+				}
+				if got, _ := p.Value(); got != s.vec[idx] {
+					t.Errorf("MerkleTree Test failed : want proof value %d got %d", s.vec[idx], got)
+				}
+				if !VerifyProof(root, p, HashSHA256) {
+					t.Errorf("MerkleTree Test failed : valid proof for index %d of %d (chunk %d) was rejected", idx, size, chunkSize)
+				}
+
+				//a proof that went over the wire must still verify
+				var received MerkleProof
+				if err := received.DeserializeFrom(p.Serialized()); err != nil || !VerifyProof(root, &received, HashSHA256) {
+					t.Errorf("MerkleTree Test failed : serialized proof for index %d was rejected (err %v)", idx, err)
+				}
+
+				//tampering with the element must be detected
+				p.Chunk[idx%chunkSize]++
+				if VerifyProof(root, p, HashSHA256) {
+					t.Errorf("MerkleTree Test failed : tampered proof for index %d was accepted", idx)
+				}
+				p.Chunk[idx%chunkSize]--
+
+				//moving the proof to another chunk must be detected
+				p.Index = (idx + chunkSize) % size
+				if p.Index/chunkSize != idx/chunkSize && VerifyProof(root, p, HashSHA256) {
+					t.Errorf("MerkleTree Test failed : proof for index %d was accepted for index %d", idx, p.Index)
+				}
+			}
+		}
+	}
+}
+
+func TestMerkleTreeRoot(t *testing.T) {
+	var a, b Intvector
+	a.Insert(1, 2, 3, 4, 5)
+	b.Insert(1, 2, 3, 4, 6)
+
+	ma, _ := NewMerkleTree(&a, 2, HashSHA256)
+	mb, _ := NewMerkleTree(&b, 2, HashSHA256)
+	if bytes.Equal(ma.Root(), mb.Root()) {
+		t.Error("MerkleTree Root Test failed : different vectors have the same root")
+	}
+
+	//the tree is a snapshot, changing the vector must not change the root
+	root := append([]byte{}, ma.Root()...)
+	a.Set(0, 100)
+	if !bytes.Equal(root, ma.Root()) {
+		t.Error("MerkleTree Root Test failed : root changed with the vector")
+	}
+
+	if _, err := NewMerkleTree(&a, 0, HashSHA256); err == nil {
+		t.Error("MerkleTree Test failed : should return error for chunk size 0")
+	}
+	if _, err := ma.Proof(5); err == nil {
+		t.Error("MerkleTree Test failed : Proof should return error for out of range index")
+	}
+
+	var empty Intvector
+	me, _ := NewMerkleTree(&empty, 4, HashSHA256)
+	if len(me.Root()) != 32 {
+		t.Errorf("MerkleTree Test failed : want a 32 byte root for empty vector, got %d bytes", len(me.Root()))
+	}
+}
@@ -0,0 +1,218 @@
+package intvector
+
+import (
+	"sync"
+)
+
+//Event describes a change to a vector, it is one of Pushed, Inserted, Removed, Set, Reordered and Cleared
+type Event interface {
+	event()
+}
+
+//Pushed is sent when Value was added at the back of the vector
+type Pushed struct {
+	Value int
+}
+
+//Inserted is sent when Values were inserted so that the first of them is at Idx
+type Inserted struct {
+	Idx    int
+	Values []int
+}
+
+//Removed is sent when Value was removed from Idx
+type Removed struct {
+	Idx   int
+	Value int
+}
+
+//Set is sent when the element at Idx changed from Old to New
+type Set struct {
+	Idx      int
+	Old, New int
+}
+
+//Reordered is sent when the elements were moved around without changing which elements there are,
+//by Sort, Reverse or Swap
+type Reordered struct{}
+
+//Cleared is sent when all the elements were removed
+type Cleared struct{}
+
+func (Pushed) event()    {}
+func (Inserted) event()  {}
+func (Removed) event()   {}
+func (Set) event()       {}
+func (Reordered) event() {}
+func (Cleared) event()   {}
+
+//Subscription is a subscriber registered with Subscribe or SubscribeChan
+type Subscription struct {
+	v *Intvector
+	f func(Event)
+	//edits is set instead of f by the subscribers inside this package that need the exact edits
+	edits func(edit)
+
+	//mu guards the channel so that Unsubscribe can be called from the goroutine reading it
+	mu      sync.Mutex
+	ch      chan Event
+	dropped int
+	closed  bool
+}
+
+//Subscribe registers f to be called with every change to the vector, after the change was made
+//and on the goroutine that made it. Every mutating method sends the events that describe it, Undo and Redo
+//send the events of the reverted or repeated change. Methods that rewrite the whole vector such as
+//ScaleBy, MakeUnique, RemoveAll, DeserializeFrom and Restore send Cleared followed by Inserted at 0,
+//a transaction sends the events of its calls once it has committed
+func (v *Intvector) Subscribe(f func(Event)) *Subscription {
+	s := &Subscription{v: v, f: f}
+	v.observers = append(v.observers, s)
+	return s
+}
+
+//SubscribeChan returns a channel that receives every change to the vector and holds up to buffer events.
+//The vector never waits for the reader, an event that does not fit into the buffer is dropped and counted
+//in Dropped, so a reader that sees Dropped grow has to read the vector again. Unsubscribe closes the channel
+func (v *Intvector) SubscribeChan(buffer int) (<-chan Event, *Subscription) {
+	s := &Subscription{v: v, ch: make(chan Event, buffer)}
+	v.observers = append(v.observers, s)
+	return s.ch, s
+}
+
+//Unsubscribe stops the delivery of events, it can be called more than once, from inside a callback
+//and from the goroutine reading the channel
+func (s *Subscription) Unsubscribe() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return
+	}
+	//the subscription is only removed from the vector by the next notify, so that this does not race with it
+	s.closed = true
+	if s.ch != nil {
+		close(s.ch)
+	}
+}
+
+//Dropped returns the number of events that did not fit into the channel of the subscription
+func (s *Subscription) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dropped
+}
+
+//subscribeEdits registers f to be called with every edit right after it was applied
+func (v *Intvector) subscribeEdits(f func(edit)) *Subscription {
+	s := &Subscription{v: v, edits: f}
+	v.observers = append(v.observers, s)
+	return s
+}
+
+//deliver passes an event to the subscriber and returns false if it has unsubscribed
+func (s *Subscription) deliver(e Event) bool {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return false
+	}
+	if s.ch != nil {
+		select {
+		case s.ch <- e:
+		default:
+			s.dropped++
+		}
+		s.mu.Unlock()
+		return true
+	}
+	//the callback runs without the lock so that it can unsubscribe
+	s.mu.Unlock()
+	s.f(e)
+	return true
+}
+
+//deliverEdit passes an edit to a subscriber created by subscribeEdits and returns false if it has unsubscribed
+func (s *Subscription) deliverEdit(e edit) bool {
+	if s.isClosed() {
+		return false
+	}
+	s.edits(e)
+	return true
+}
+
+//notify sends an edit that has just been applied to the observers
+func (v *Intvector) notify(e edit) {
+	if len(v.observers) == 0 {
+		return
+	}
+	v.dispatch(e, nil)
+}
+
+//dispatch passes an edit to the subscribers of edits and the events describing it to the others,
+//events is computed from the edit if it is nil
+func (v *Intvector) dispatch(e edit, events []Event) {
+	pruned := false
+	//a callback may subscribe or unsubscribe, which must not change what this loop iterates over
+	for _, o := range v.observers[:len(v.observers):len(v.observers)] {
+		if o.edits != nil {
+			pruned = !o.deliverEdit(e) || pruned
+			continue
+		}
+		if events == nil {
+			events = e.events(len(v.vec))
+		}
+		for _, ev := range events {
+			if !o.deliver(ev) {
+				pruned = true
+				break
+			}
+		}
+	}
+	if pruned {
+		observers := make([]*Subscription, 0, len(v.observers))
+		for _, o := range v.observers {
+			if !o.isClosed() {
+				observers = append(observers, o)
+			}
+		}
+		v.observers = observers
+	}
+}
+
+//isClosed returns true if the subscriber has unsubscribed
+func (s *Subscription) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.closed
+}
+
+//events returns the events describing the edit, size is the size of the vector after it
+func (e edit) events(size int) []Event {
+	switch e.kind {
+	case editInsert:
+		if len(e.vals) == 1 && e.idx == size-1 {
+			return []Event{Pushed{Value: e.vals[0]}}
+		}
+		return []Event{Inserted{Idx: e.idx, Values: append([]int{}, e.vals...)}}
+	case editRemove:
+		events := make([]Event, len(e.vals))
+		for i, val := range e.vals {
+			events[i] = Removed{Idx: e.idx, Value: val}
+		}
+		return events
+	case editSet:
+		return []Event{Set{Idx: e.idx, Old: e.old, New: e.new}}
+	case editSwap, editReverse, editReorder:
+		return []Event{Reordered{}}
+	case editReplace:
+		events := []Event{}
+		if len(e.before) > 0 {
+			events = append(events, Cleared{})
+		}
+		if len(e.vals) > 0 {
+			events = append(events, Inserted{Idx: 0, Values: append([]int{}, e.vals...)})
+		}
+		return events
+	}
+	return nil
+}
@@ -0,0 +1,161 @@
+package intvector
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+//mirror applies the events to a plain slice, a subscriber that keeps a copy of the vector would do the same
+func mirror(s []int, e Event) []int {
+	switch e := e.(type) {
+	case Pushed:
+		return append(s, e.Value)
+	case Inserted:
+		return append(s[:e.Idx], append(append([]int{}, e.Values...), s[e.Idx:]...)...)
+	case Removed:
+		return append(s[:e.Idx], s[e.Idx+1:]...)
+	case Set:
+		s[e.Idx] = e.New
+	case Cleared:
+		return nil
+	}
+	return s
+}
+
+func TestSubscribe(t *testing.T) {
+	var v Intvector
+	var events []Event
+	v.Subscribe(func(e Event) {
+		events = append(events, e)
+	})
+
+	v.Push(1)
+	v.Insert(2, 3)
+	v.Set(0, 5)
+	v.RemoveAt(1)
+	v.Sort()
+	v.Clear()
+
+	want := []Event{
+		Pushed{Value: 1},
+		Inserted{Idx: 1, Values: []int{2, 3}},
+		Set{Idx: 0, Old: 1, New: 5},
+		Removed{Idx: 1, Value: 2},
+		Reordered{},
+		Cleared{},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("Subscribe Test failed : want %v got %v", want, events)
+	}
+}
+
+func TestSubscribeMirror(t *testing.T) {
+	var v Intvector
+	var copy []int
+	v.Subscribe(func(e Event) {
+		if _, ok := e.(Reordered); ok {
+			//a reordering has to be followed by reading the vector again
+			copy = append([]int{}, v.vec...)
+			return
+		}
+		copy = mirror(copy, e)
+	})
+
+	v.EnableHistory(100)
+	v.Insert(5, 3, 8, 3)
+	v.Unshift(1)
+	v.SortedPush(4)
+	v.Pop()
+	v.Shift()
+	v.RemoveFirstOf(3)
+	v.UniquePush(9)
+	v.Reverse()
+	v.Swap(0, 1)
+	v.RemoveAll(3)
+	v.ScaleBy(2)
+	v.MakeUnique()
+	v.DeserializeFrom(v.Serialized(), true)
+	v.Tx(func(tx *IntvectorTx) error {
+		tx.Push(7)
+		tx.Set(0, 0)
+		tx.RemoveAt(1)
+		return nil
+	})
+	if !reflect.DeepEqual(copy, v.vec) {
+		t.Fatalf("SubscribeMirror Test failed : want %v got %v", v.vec, copy)
+	}
+
+	//undo and redo are changes like any other
+	for v.CanUndo() {
+		v.Undo()
+		if !reflect.DeepEqual(copy, v.vec) {
+			t.Fatalf("SubscribeMirror Test failed : after Undo want %v got %v", v.vec, copy)
+		}
+	}
+	for v.CanRedo() {
+		v.Redo()
+		if !reflect.DeepEqual(copy, v.vec) {
+			t.Fatalf("SubscribeMirror Test failed : after Redo want %v got %v", v.vec, copy)
+		}
+	}
+}
+
+func TestSubscribeChan(t *testing.T) {
+	var v Intvector
+	ch, sub := v.SubscribeChan(2)
+
+	v.Push(1)
+	v.Push(2)
+	v.Push(3)
+	if sub.Dropped() != 1 {
+		t.Errorf("SubscribeChan Test failed : want 1 dropped event got %d", sub.Dropped())
+	}
+	if e := <-ch; e != (Pushed{Value: 1}) {
+		t.Errorf("SubscribeChan Test failed : want %v got %v", Pushed{Value: 1}, e)
+	}
+	if e := <-ch; e != (Pushed{Value: 2}) {
+		t.Errorf("SubscribeChan Test failed : want %v got %v", Pushed{Value: 2}, e)
+	}
+
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	v.Push(4)
+	if _, ok := <-ch; ok {
+		t.Error("SubscribeChan Test failed : want the channel closed after Unsubscribe")
+	}
+	if len(v.observers) != 0 {
+		t.Errorf("SubscribeChan Test failed : want no observers left got %d", len(v.observers))
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	var v Intvector
+	calls := 0
+	var sub *Subscription
+	sub = v.Subscribe(func(e Event) {
+		calls++
+		sub.Unsubscribe()
+	})
+	other := 0
+	v.Subscribe(func(e Event) {
+		other++
+	})
+
+	v.Insert(1, 2, 3)
+	v.Reverse()
+	if calls != 1 || other != 2 {
+		t.Errorf("Unsubscribe Test failed : want 1 and 2 calls got %d and %d", calls, other)
+	}
+
+	//a rolled back transaction sends nothing
+	v.Tx(func(tx *IntvectorTx) error {
+		tx.Push(1)
+		return errTestRollback
+	})
+	if other != 2 {
+		t.Errorf("Unsubscribe Test failed : want no events from a rolled back transaction got %d", other-2)
+	}
+}
+
+var errTestRollback = errors.New("rollback")
@@ -0,0 +1,189 @@
+package intvector
+
+import (
+	"errors"
+	"strconv"
+)
+
+//PrefixSums is a snapshot of the running sums of a vector and answers range sum queries in O(1)
+//It does not follow later changes to the vector, a new snapshot has to be taken for that
+type PrefixSums struct {
+	sums []int
+}
+
+//NewPrefixSums takes a prefix sum snapshot of the given vector
+func NewPrefixSums(v *Intvector) *PrefixSums {
+	sums := make([]int, len(v.vec)+1)
+	for i, val := range v.vec {
+		sums[i+1] = sums[i] + val
+	}
+	return &PrefixSums{sums: sums}
+}
+
+//Size returns the number of elements covered by the snapshot
+func (p *PrefixSums) Size() int {
+	return len(p.sums) - 1
+}
+
+//Sum returns the sum of the elements in the range [i, j)
+func (p *PrefixSums) Sum(i int, j int) (int, error) {
+	if err := checkRange(i, j, p.Size()); err != nil {
+		return 0, err
+	}
+	return p.sums[j] - p.sums[i], nil
+}
+
+//SegmentTree keeps the sum, minimum and maximum of every node of a binary tree built over a vector
+//Point updates and range queries both take O(log n)
+//The tree only follows the vector if it is wired to its Set calls, e.g.
+//	v.OnSet(func(idx int, value int) { t.Set(idx, value) })
+type SegmentTree struct {
+	n   int
+	sum []int
+	min []int
+	max []int
+}
+
+//NewSegmentTree builds a segment tree over the current elements of the given vector in O(n)
+func NewSegmentTree(v *Intvector) *SegmentTree {
+	n := len(v.vec)
+	t := &SegmentTree{
+		n:   n,
+		sum: make([]int, 2*n),
+		min: make([]int, 2*n),
+		max: make([]int, 2*n),
+	}
+
+	//leaves live in the second half of the arrays, the parent of node i is i/2
+	for i, val := range v.vec {
+		t.sum[n+i] = val
+		t.min[n+i] = val
+		t.max[n+i] = val
+	}
+	for i := n - 1; i > 0; i-- {
+		t.pull(i)
+	}
+	return t
+}
+
+//pull recomputes node i from its two children
+func (t *SegmentTree) pull(i int) {
+	l, r := 2*i, 2*i+1
+	t.sum[i] = t.sum[l] + t.sum[r]
+	t.min[i] = t.min[l]
+	if t.min[r] < t.min[i] {
+		t.min[i] = t.min[r]
+	}
+	t.max[i] = t.max[l]
+	if t.max[r] > t.max[i] {
+		t.max[i] = t.max[r]
+	}
+}
+
+//Size returns the number of elements in the tree
+func (t *SegmentTree) Size() int {
+	return t.n
+}
+
+//Set updates the element at the given idx and all the nodes above it
+func (t *SegmentTree) Set(idx int, value int) error {
+	if idx < 0 || idx >= t.n {
+		return errors.New("idx out of range for tree of size " + strconv.Itoa(t.n))
+	}
+
+	i := idx + t.n
+	t.sum[i] = value
+	t.min[i] = value
+	t.max[i] = value
+	for i /= 2; i > 0; i /= 2 {
+		t.pull(i)
+	}
+	return nil
+}
+
+//Sum returns the sum of the elements in the range [i, j)
+func (t *SegmentTree) Sum(i int, j int) (int, error) {
+	if err := checkRange(i, j, t.n); err != nil {
+		return 0, err
+	}
+
+	s := 0
+	for l, r := i+t.n, j+t.n; l < r; l, r = l/2, r/2 {
+		if l%2 == 1 {
+			s += t.sum[l]
+			l++
+		}
+		if r%2 == 1 {
+			r--
+			s += t.sum[r]
+		}
+	}
+	return s, nil
+}
+
+//Min returns the minimum of the elements in the range [i, j), the range must not be empty
+func (t *SegmentTree) Min(i int, j int) (int, error) {
+	if err := checkNonEmptyRange(i, j, t.n); err != nil {
+		return 0, err
+	}
+
+	min := t.min[i+t.n]
+	for l, r := i+t.n, j+t.n; l < r; l, r = l/2, r/2 {
+		if l%2 == 1 {
+			if t.min[l] < min {
+				min = t.min[l]
+			}
+			l++
+		}
+		if r%2 == 1 {
+			r--
+			if t.min[r] < min {
+				min = t.min[r]
+			}
+		}
+	}
+	return min, nil
+}
+
+//Max returns the maximum of the elements in the range [i, j), the range must not be empty
+func (t *SegmentTree) Max(i int, j int) (int, error) {
+	if err := checkNonEmptyRange(i, j, t.n); err != nil {
+		return 0, err
+	}
+
+	max := t.max[i+t.n]
+	for l, r := i+t.n, j+t.n; l < r; l, r = l/2, r/2 {
+		if l%2 == 1 {
+			if t.max[l] > max {
+				max = t.max[l]
+			}
+			l++
+		}
+		if r%2 == 1 {
+			r--
+			if t.max[r] > max {
+				max = t.max[r]
+			}
+		}
+	}
+	return max, nil
+}
+
+//checkRange validates the half open range [i, j) against a structure of size n
+func checkRange(i int, j int, n int) error {
+	if i < 0 || j > n || i > j {
+		return errors.New("Invalid range [" + strconv.Itoa(i) + ", " + strconv.Itoa(j) + ") for size " + strconv.Itoa(n))
+	}
+	return nil
+}
+
+//checkNonEmptyRange is checkRange for queries that have no answer on an empty range
+func checkNonEmptyRange(i int, j int, n int) error {
+	if err := checkRange(i, j, n); err != nil {
+		return err
+	}
+	if i == j {
+		return errors.New("Empty range")
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package intvector
+
+import (
+	"math/rand"
+	"testing"
+)
+
+//bruteSum, bruteMin and bruteMax are the reference implementations for the range queries
+func bruteSum(s []int, i int, j int) int {
+	sum := 0
+	for _, v := range s[i:j] {
+		sum += v
+	}
+	return sum
+}
+
+func bruteMin(s []int, i int, j int) int {
+	min := s[i]
+	for _, v := range s[i:j] {
+		if v < min {
+			min = v
+		}
+	}
+	return min
+}
+
+func bruteMax(s []int, i int, j int) int {
+	max := s[i]
+	for _, v := range s[i:j] {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
+
+func TestPrefixSums(t *testing.T) {
+	var s Intvector
+	s.Insert([]int{3, -1, 4, 1, -5, 9, 2, -6}...)
+	p := NewPrefixSums(&s)
+
+	if p.Size() != s.Size() {
+		t.Errorf("PrefixSums Test failed : want size %d got %d", s.Size(), p.Size())
+	}
+
+	for i := 0; i <= s.Size(); i++ {
+		for j := i; j <= s.Size(); j++ {
+			want := bruteSum(s.vec, i, j)
+			got, err := p.Sum(i, j)
+			if err != nil || want != got {
+				t.Errorf("PrefixSums Test failed : want %d got %d (err %v) for range [%d, %d)", want, got, err, i, j)
+			}
+		}
+	}
+
+	if _, err := p.Sum(-1, 2); err == nil {
+		t.Error("PrefixSums Test failed : should return error for negative index")
+	}
+	if _, err := p.Sum(3, 2); err == nil {
+		t.Error("PrefixSums Test failed : should return error for inverted range")
+	}
+	if _, err := p.Sum(0, s.Size()+1); err == nil {
+		t.Error("PrefixSums Test failed : should return error for out of range index")
+	}
+
+	//the snapshot must not follow the vector
+	s.Set(0, 100)
+	if got, _ := p.Sum(0, 1); got != 3 {
+		t.Errorf("PrefixSums Test failed : snapshot changed with the vector, want %d got %d", 3, got)
+	}
+}
+
+func TestSegmentTree(t *testing.T) {
+	var s Intvector
+	for i := 0; i < 37; i++ {
+		s.Push(rand.Intn(200) - 100)
+	}
+
+	tree := NewSegmentTree(&s)
+	s.OnSet(func(idx int, value int) {
+		if err := tree.Set(idx, value); err != nil {
+			t.Errorf("SegmentTree Test failed : Set returned error %s", err)
+		}
+	})
+
+	for round := 0; round < 200; round++ {
+		s.Set(rand.Intn(s.Size()), rand.Intn(200)-100)
+
+		i := rand.Intn(s.Size())
+		j := i + 1 + rand.Intn(s.Size()-i)
+
+		want := bruteSum(s.vec, i, j)
+		got, err := tree.Sum(i, j)
+		if err != nil || want != got {
+			t.Errorf("SegmentTree Sum Test failed : want %d got %d (err %v) for range [%d, %d)", want, got, err, i, j)
+		}
+
+		want = bruteMin(s.vec, i, j)
+		got, err = tree.Min(i, j)
+		if err != nil || want != got {
+			t.Errorf("SegmentTree Min Test failed : want %d got %d (err %v) for range [%d, %d)", want, got, err, i, j)
+		}
+
+		want = bruteMax(s.vec, i, j)
+		got, err = tree.Max(i, j)
+		if err != nil || want != got {
+			t.Errorf("SegmentTree Max Test failed : want %d got %d (err %v) for range [%d, %d)", want, got, err, i, j)
+		}
+	}
+
+	if _, err := tree.Min(4, 4); err == nil {
+		t.Error("SegmentTree Test failed : Min should return error for empty range")
+	}
+	if got, err := tree.Sum(4, 4); err != nil || got != 0 {
+		t.Errorf("SegmentTree Test failed : want sum 0 for empty range, got %d (err %v)", got, err)
+	}
+	if err := tree.Set(s.Size(), 1); err == nil {
+		t.Error("SegmentTree Test failed : Set should return error for out of range index")
+	}
+
+	var empty Intvector
+	if _, err := NewSegmentTree(&empty).Max(0, 0); err == nil {
+		t.Error("SegmentTree Test failed : Max should return error for empty tree")
+	}
+}
+
+func BenchmarkSegmentTreeSum(b *testing.B) {
+	var s Intvector
+	for i := 0; i < 1<<16; i++ {
+		s.Push(i)
+	}
+	tree := NewSegmentTree(&s)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Sum(i%1000, 1<<15+i%1000)
+	}
+}
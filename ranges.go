@@ -0,0 +1,185 @@
+package intvector
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+//maxRangeSize is the largest number of values ParseRanges expands a string to
+const maxRangeSize = 1 << 24
+
+//ParseRanges parses a comma separated list of values and ranges such as "1-5,8,10-12" into a vector.
+//A range is inclusive and may count down, "5-1", and numbers may be negative, "-5--1".
+//A range may have a step, "0-100:5" is every fifth value from 0 to 100. An item starting with ! is an
+//exclusion, "1-10,!5" or "1-10,!4-6", whose values are removed wherever they are in the list.
+//The values are in the order of the list, repeated values are kept, use Sort and MakeUnique to normalize them.
+//An empty string is an empty vector
+func ParseRanges(s string) (*Intvector, error) {
+	var vals, excluded []int
+	if strings.TrimSpace(s) != "" {
+		for _, item := range strings.Split(s, ",") {
+			item = strings.TrimSpace(item)
+			exclude := strings.HasPrefix(item, "!")
+			var err error
+			if exclude {
+				excluded, err = appendRange(excluded, strings.TrimSpace(item[1:]), len(vals)+len(excluded))
+			} else {
+				vals, err = appendRange(vals, item, len(vals)+len(excluded))
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if len(excluded) > 0 {
+		drop := make(map[int]bool, len(excluded))
+		for _, n := range excluded {
+			drop[n] = true
+		}
+		kept := vals[:0]
+		for _, n := range vals {
+			if !drop[n] {
+				kept = append(kept, n)
+			}
+		}
+		vals = kept
+	}
+	return &Intvector{vec: vals}, nil
+}
+
+//appendRange appends the values of a single value or range to s, total is the number of values parsed so far
+func appendRange(s []int, item string, total int) ([]int, error) {
+	invalid := fmt.Errorf("Invalid range %q", item)
+	if item == "" {
+		return nil, invalid
+	}
+
+	body, step := item, 1
+	if i := strings.IndexByte(item, ':'); i >= 0 {
+		body = item[:i]
+		n, err := strconv.Atoi(strings.TrimSpace(item[i+1:]))
+		if err != nil || n <= 0 {
+			return nil, invalid
+		}
+		step = n
+	}
+
+	if body == "" {
+		return nil, invalid
+	}
+	//the separator is the first - after the sign of the first number
+	sep := strings.IndexByte(body[1:], '-') + 1
+	if sep == 0 {
+		//a step needs a range
+		if body != item {
+			return nil, invalid
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(body))
+		if err != nil {
+			return nil, invalid
+		}
+		return append(s, n), nil
+	}
+	lo, err := strconv.Atoi(strings.TrimSpace(body[:sep]))
+	if err != nil {
+		return nil, invalid
+	}
+	hi, err := strconv.Atoi(strings.TrimSpace(body[sep+1:]))
+	if err != nil {
+		return nil, invalid
+	}
+
+	//the count is computed unsigned so that ranges spanning most of the int values do not overflow
+	var span uint64
+	if lo <= hi {
+		span = uint64(hi) - uint64(lo)
+	} else {
+		span = uint64(lo) - uint64(hi)
+	}
+	//span/step is checked before adding 1, which overflows for the range of all int values
+	count := span / uint64(step)
+	if count >= maxRangeSize || uint64(total)+count+1 > maxRangeSize {
+		return nil, fmt.Errorf("Range %q has too many values", item)
+	}
+	n := lo
+	for i := uint64(0); i <= count; i++ {
+		s = append(s, n)
+		if lo <= hi {
+			n += step
+		} else {
+			n -= step
+		}
+	}
+	return s, nil
+}
+
+//FormatRanges returns the vector as a comma separated list in which every run of at least three
+//consecutive ascending values is collapsed into a range, [1 2 3 4 5 8 10 11 12] is "1-5,8,10-12".
+//The values are taken in order, so Sort and MakeUnique the vector first for the shortest string.
+//ParseRanges of the result is the vector again
+func (v *Intvector) FormatRanges() string {
+	var b []byte
+	for i := 0; i < len(v.vec); {
+		j := i
+		for j+1 < len(v.vec) && v.vec[j] != math.MaxInt && v.vec[j+1] == v.vec[j]+1 {
+			j++
+		}
+		if len(b) > 0 {
+			b = append(b, ',')
+		}
+		switch j - i {
+		case 0:
+			b = strconv.AppendInt(b, int64(v.vec[i]), 10)
+		case 1:
+			//a pair is shorter as two values
+			j = i
+			b = strconv.AppendInt(b, int64(v.vec[i]), 10)
+		default:
+			b = strconv.AppendInt(b, int64(v.vec[i]), 10)
+			b = append(b, '-')
+			b = strconv.AppendInt(b, int64(v.vec[j]), 10)
+		}
+		i = j + 1
+	}
+	return string(b)
+}
+
+//rangesFlag is the flag.Value returned by RangesFlag
+type rangesFlag struct {
+	v   *Intvector
+	set bool
+}
+
+//RangesFlag returns a flag.Value that parses range strings into the vector, so that it can be passed to
+//flag.Var. The first use of the flag replaces the contents of the vector, which are its default,
+//and repeating the flag appends to it, so -ports 80,443 -ports 8000-8080 has all of them.
+//Intvector can not implement flag.Value itself as its Set method sets an element
+func (v *Intvector) RangesFlag() flag.Value {
+	return &rangesFlag{v: v}
+}
+
+func (f *rangesFlag) String() string {
+	//the flag package calls String on a zero value to find out whether the default is empty
+	if f == nil || f.v == nil {
+		return ""
+	}
+	return f.v.FormatRanges()
+}
+
+func (f *rangesFlag) Set(s string) error {
+	p, err := ParseRanges(s)
+	if err != nil {
+		return err
+	}
+	if !f.set {
+		f.v.replace(p.vec)
+		f.set = true
+		return nil
+	}
+	f.v.Insert(p.vec...)
+	return nil
+}
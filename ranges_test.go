@@ -0,0 +1,122 @@
+package intvector
+
+import (
+	"flag"
+	"math"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestParseRanges(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []int
+	}{
+		{"", nil},
+		{" ", nil},
+		{"7", []int{7}},
+		{"1-5,8,10-12", []int{1, 2, 3, 4, 5, 8, 10, 11, 12}},
+		{" 1 - 3 , 2 ", []int{1, 2, 3, 2}},
+		{"5-1", []int{5, 4, 3, 2, 1}},
+		{"-5--3,-1-1", []int{-5, -4, -3, -1, 0, 1}},
+		{"0-10:5", []int{0, 5, 10}},
+		{"1-10:4", []int{1, 5, 9}},
+		{"10-1:3", []int{10, 7, 4, 1}},
+		{"1-10,!5,!7-9", []int{1, 2, 3, 4, 6, 10}},
+		{"!2,1-3,2", []int{1, 3}},
+		{"!1-3", []int{}},
+	}
+	for _, c := range cases {
+		v, err := ParseRanges(c.in)
+		if err != nil || len(v.vec) != len(c.want) || len(c.want) > 0 && !reflect.DeepEqual(v.vec, c.want) {
+			t.Errorf("ParseRanges Test failed : %q want %v got %v, %v", c.in, c.want, v, err)
+		}
+	}
+
+	for _, in := range []string{",", "1,", "x", "1-", "-", "1-x", "1--", "5:2", "1-5:0", "1-5:-1", "!", "1-2-3", "0-100000000", ":1", "1,:5", "!:3", " :2"} {
+		if _, err := ParseRanges(in); err == nil {
+			t.Errorf("ParseRanges Test failed : %q want an error", in)
+		}
+	}
+
+	//the extremes of int do not overflow
+	min, max := strconv.Itoa(math.MinInt), strconv.Itoa(math.MaxInt)
+	v, err := ParseRanges(max + "-" + max + "," + min + "-" + min + ":7")
+	if err != nil || !reflect.DeepEqual(v.vec, []int{math.MaxInt, math.MinInt}) {
+		t.Errorf("ParseRanges Test failed : got %v, %v for the extremes", v, err)
+	}
+	if _, err := ParseRanges(min + "-" + max); err == nil {
+		t.Error("ParseRanges Test failed : want an error for the range of all int values")
+	}
+}
+
+func TestFormatRanges(t *testing.T) {
+	cases := []struct {
+		in   []int
+		want string
+	}{
+		{nil, ""},
+		{[]int{1, 2, 3, 4, 5, 8, 10, 11, 12}, "1-5,8,10-12"},
+		{[]int{1, 2, 4, 5}, "1,2,4,5"},
+		{[]int{-3, -2, -1, 0, 5}, "-3-0,5"},
+		{[]int{-7, -6, -5}, "-7--5"},
+		{[]int{3, 2, 1}, "3,2,1"},
+		{[]int{1, 1, 2, 3}, "1,1-3"},
+		{[]int{math.MaxInt - 2, math.MaxInt - 1, math.MaxInt, math.MinInt}, strconv.Itoa(math.MaxInt-2) + "-" + strconv.Itoa(math.MaxInt) + "," + strconv.Itoa(math.MinInt)},
+	}
+	for _, c := range cases {
+		v := Intvector{}
+		v.Insert(c.in...)
+		got := v.FormatRanges()
+		if got != c.want {
+			t.Errorf("FormatRanges Test failed : %v want %q got %q", c.in, c.want, got)
+		}
+		p, err := ParseRanges(got)
+		if err != nil || len(p.vec) != len(c.in) || len(c.in) > 0 && !reflect.DeepEqual(p.vec, c.in) {
+			t.Errorf("FormatRanges Test failed : %q does not parse back to %v got %v, %v", got, c.in, p, err)
+		}
+	}
+
+	v, _ := ParseRanges("8,3-5,4,1")
+	v.Sort()
+	v.MakeUnique()
+	if got := v.FormatRanges(); got != "1,3-5,8" {
+		t.Errorf("FormatRanges Test failed : want 1,3-5,8 got %q", got)
+	}
+}
+
+func TestRangesFlag(t *testing.T) {
+	ports := Intvector{}
+	ports.Insert(80)
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.Var(ports.RangesFlag(), "ports", "ports to listen on")
+
+	if got := fs.Lookup("ports").DefValue; got != "80" {
+		t.Errorf("RangesFlag Test failed : want default 80 got %q", got)
+	}
+	if err := fs.Parse([]string{"-ports", "8000-8002,!8001", "-ports", "443"}); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ports.vec, []int{8000, 8002, 443}) {
+		t.Errorf("RangesFlag Test failed : want [8000 8002 443] got %v", ports.vec)
+	}
+	if got := fs.Lookup("ports").Value.String(); got != "8000,8002,443" {
+		t.Errorf("RangesFlag Test failed : want 8000,8002,443 got %q", got)
+	}
+
+	fs.SetOutput(discard{})
+	if err := fs.Parse([]string{"-ports", "1-x"}); err == nil {
+		t.Error("RangesFlag Test failed : want an error for an invalid range")
+	}
+	if err := fs.Parse([]string{"-ports", ":1"}); err == nil {
+		t.Error("RangesFlag Test failed : want an error for a step without a range")
+	}
+}
+
+//discard is an io.Writer that drops everything written to it
+type discard struct{}
+
+func (discard) Write(b []byte) (int, error) {
+	return len(b), nil
+}
@@ -0,0 +1,366 @@
+package intvector
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+)
+
+//ErrGap is returned by Apply when the first op does not follow the last op applied to the replica,
+//the replica then has to catch up with Sync, which falls back to a full snapshot if needed
+var ErrGap = errors.New("Gap in the op log")
+
+//ErrDiverged is returned when a replica does not have the same contents as the leader after an update
+var ErrDiverged = errors.New("Replica diverged from the leader")
+
+//OpKind identifies the mutation an Op describes
+type OpKind byte
+
+const (
+	//OpInsert inserts Values so that the first of them is at Idx
+	OpInsert OpKind = iota + 1
+	//OpRemove removes len(Values) elements from Idx on, Values holds the removed elements
+	OpRemove
+	//OpSet sets the element at Idx to Values[0]
+	OpSet
+	//OpSwap swaps the elements at Idx and Idx2
+	OpSwap
+	//OpReverse reverses the whole vector
+	OpReverse
+	//OpReplace replaces the whole contents with Values
+	OpReplace
+)
+
+//Op is a single mutation of a vector in the op log, Seq numbers the ops of a leader from 1 on without gaps
+type Op struct {
+	Seq    uint64
+	Kind   OpKind
+	Idx    int
+	Idx2   int
+	Values []int
+}
+
+//opFromEdit converts an edit into an op, the values are copied because the edit may share them with the vector
+func opFromEdit(e edit) Op {
+	switch e.kind {
+	case editInsert:
+		return Op{Kind: OpInsert, Idx: e.idx, Values: append([]int(nil), e.vals...)}
+	case editRemove:
+		return Op{Kind: OpRemove, Idx: e.idx, Values: append([]int(nil), e.vals...)}
+	case editSet:
+		return Op{Kind: OpSet, Idx: e.idx, Values: []int{e.new}}
+	case editSwap:
+		return Op{Kind: OpSwap, Idx: e.idx, Idx2: e.idx2}
+	case editReverse:
+		return Op{Kind: OpReverse}
+	}
+	return Op{Kind: OpReplace, Values: append([]int(nil), e.vals...)}
+}
+
+//resize checks that the op fits a vector of n elements and returns the number of elements after it
+func (op Op) resize(n int) (int, error) {
+	switch op.Kind {
+	case OpInsert:
+		if op.Idx < 0 || op.Idx > n {
+			return 0, ErrDiverged
+		}
+		return n + len(op.Values), nil
+	case OpRemove:
+		if op.Idx < 0 || op.Idx > n || len(op.Values) > n-op.Idx {
+			return 0, ErrDiverged
+		}
+		return n - len(op.Values), nil
+	case OpSet:
+		if op.Idx < 0 || op.Idx >= n || len(op.Values) != 1 {
+			return 0, ErrDiverged
+		}
+		return n, nil
+	case OpSwap:
+		if op.Idx < 0 || op.Idx >= n || op.Idx2 < 0 || op.Idx2 >= n {
+			return 0, ErrDiverged
+		}
+		return n, nil
+	case OpReverse:
+		return n, nil
+	case OpReplace:
+		return len(op.Values), nil
+	}
+	return 0, errors.New("Unknown op")
+}
+
+//edit converts the op into the edit it describes for the vector s, it fails if the op does not fit s
+func (op Op) edit(s []int) (edit, error) {
+	if _, err := op.resize(len(s)); err != nil {
+		return edit{}, err
+	}
+	switch op.Kind {
+	case OpInsert:
+		return edit{kind: editInsert, idx: op.Idx, vals: append([]int{}, op.Values...)}, nil
+	case OpRemove:
+		return edit{kind: editRemove, idx: op.Idx, vals: append([]int{}, s[op.Idx:op.Idx+len(op.Values)]...)}, nil
+	case OpSet:
+		return edit{kind: editSet, idx: op.Idx, old: s[op.Idx], new: op.Values[0]}, nil
+	case OpSwap:
+		return edit{kind: editSwap, idx: op.Idx, idx2: op.Idx2}, nil
+	case OpReverse:
+		return edit{kind: editReverse}, nil
+	}
+	return edit{kind: editReplace, before: s, vals: append([]int{}, op.Values...)}, nil
+}
+
+//EncodeOps returns the binary form of the ops, every op is written as its Seq, its Kind as a single byte,
+//Idx, Idx2, the number of values and the values, all numbers as 8 byte big endian words
+func EncodeOps(ops []Op) []byte {
+	var b []byte
+	for _, op := range ops {
+		b = appendUint64(b, op.Seq)
+		b = append(b, byte(op.Kind))
+		b = appendWord(b, op.Idx)
+		b = appendWord(b, op.Idx2)
+		b = appendWord(b, len(op.Values))
+		b = appendWords(b, op.Values)
+	}
+	return b
+}
+
+//DecodeOps parses the binary form written by EncodeOps
+func DecodeOps(b []byte) ([]Op, error) {
+	ops := []Op{}
+	r := wordReader{b: b}
+	for !r.done() {
+		var op Op
+		var err error
+		if op.Seq, err = r.uint64(); err != nil {
+			return nil, err
+		}
+		if r.done() {
+			return nil, errors.New("Invalid length")
+		}
+		op.Kind = OpKind(r.b[0])
+		r.b = r.b[1:]
+		fields, err := r.words(3)
+		if err != nil {
+			return nil, err
+		}
+		op.Idx, op.Idx2 = fields[0], fields[1]
+		if fields[2] != 0 {
+			if op.Values, err = r.words(fields[2]); err != nil {
+				return nil, err
+			}
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+//OpLog records every mutation of a leader vector as an Op so that followers can be brought up to date
+//without sending the whole vector. It keeps the last ops in memory, a follower that fell further behind
+//gets a snapshot instead. It must be used from the goroutine that mutates the vector
+type OpLog struct {
+	v     *Intvector
+	sub   *Subscription
+	seq   uint64
+	limit int
+	ops   []Op
+}
+
+//NewOpLog starts recording the mutations of v, at most limit ops are kept for followers to catch up
+//A limit of 0 or less keeps none, so every follower that is behind gets a snapshot
+//If v is not empty its contents are recorded as the first op, so that empty followers start from the same state
+func NewOpLog(v *Intvector, limit int) *OpLog {
+	l := &OpLog{v: v, limit: limit}
+	if len(v.vec) > 0 {
+		l.add(Op{Kind: OpReplace, Values: append([]int{}, v.vec...)})
+	}
+	l.sub = v.subscribeEdits(func(e edit) {
+		l.add(opFromEdit(e))
+	})
+	return l
+}
+
+//add numbers the op and keeps it, dropping the oldest op if the limit is reached
+func (l *OpLog) add(op Op) {
+	l.seq++
+	op.Seq = l.seq
+	if l.limit <= 0 {
+		return
+	}
+	if len(l.ops) == l.limit {
+		copy(l.ops, l.ops[1:])
+		l.ops = l.ops[:len(l.ops)-1]
+	}
+	l.ops = append(l.ops, op)
+}
+
+//Seq returns the sequence number of the last recorded op
+func (l *OpLog) Seq() uint64 {
+	return l.seq
+}
+
+//Since returns the ops following the op numbered seq, false means they are no longer kept
+//and the follower needs a snapshot
+func (l *OpLog) Since(seq uint64) ([]Op, bool) {
+	if seq > l.seq {
+		return nil, false
+	}
+	first := l.seq - uint64(len(l.ops)) + 1
+	if seq+1 < first {
+		return nil, false
+	}
+	return append([]Op{}, l.ops[seq+1-first:]...), true
+}
+
+//Close stops recording the mutations of the vector
+func (l *OpLog) Close() {
+	l.sub.Unsubscribe()
+}
+
+//A sync is a single request and response: the follower sends the sequence number of its last op as
+//an 8 byte word and the leader answers with a frame of a kind byte ('O' for ops, 'S' for a snapshot),
+//the sequence number of its last op, the sha256 hash of its vector, the payload length as 4 bytes
+//and the payload, which is EncodeOps of the missing ops or the Serialized vector
+const (
+	syncOps      = 'O'
+	syncSnapshot = 'S'
+	syncHeader   = 1 + 8 + sha256.Size + 4
+	//maxSyncPayload bounds the memory a Sync allocates for a frame before it was checked, 512 MiB
+	//is a snapshot of 64M elements
+	maxSyncPayload = 512 << 20
+)
+
+//ServeSync answers a single Sync request of a follower on rw
+func (l *OpLog) ServeSync(rw io.ReadWriter) error {
+	var req [8]byte
+	if _, err := io.ReadFull(rw, req[:]); err != nil {
+		return err
+	}
+
+	kind := byte(syncOps)
+	var payload []byte
+	if ops, ok := l.Since(binary.BigEndian.Uint64(req[:])); ok {
+		payload = EncodeOps(ops)
+	} else {
+		kind, payload = syncSnapshot, l.v.Serialized()
+	}
+
+	hash, _ := hex.DecodeString(l.v.Hash())
+	frame := make([]byte, 0, syncHeader+len(payload))
+	frame = append(frame, kind)
+	frame = appendUint64(frame, l.seq)
+	frame = append(frame, hash...)
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(payload)))
+	frame = append(frame, payload...)
+	_, err := rw.Write(frame)
+	return err
+}
+
+//Replica is a follower copy of a vector that is kept up to date with the ops of a leader
+type Replica struct {
+	v   *Intvector
+	seq uint64
+}
+
+//NewReplica returns a follower that applies the ops of a leader to v, v should start out empty
+//or be brought up to date with Sync
+func NewReplica(v *Intvector) *Replica {
+	return &Replica{v: v}
+}
+
+//Seq returns the sequence number of the last op applied to the replica
+func (r *Replica) Seq() uint64 {
+	return r.seq
+}
+
+//Intvector returns the vector of the replica, it must only be changed through the replica
+func (r *Replica) Intvector() *Intvector {
+	return r.v
+}
+
+//Apply applies the ops to the replica in order. Ops that were already applied are skipped, so the same ops
+//can be delivered more than once. Nothing is changed if an error is returned: ErrGap if an op is missing and
+//ErrDiverged if an op does not fit the vector, which is checked for the whole batch before applying any op
+//The ops are applied like the mutating methods, so the history and the observers of the vector see them
+func (r *Replica) Apply(ops []Op) error {
+	next, n := r.seq+1, len(r.v.vec)
+	for _, op := range ops {
+		if op.Seq < next {
+			continue
+		}
+		if op.Seq != next {
+			return ErrGap
+		}
+		var err error
+		if n, err = op.resize(n); err != nil {
+			return err
+		}
+		next++
+	}
+
+	for _, op := range ops {
+		if op.Seq != r.seq+1 {
+			continue
+		}
+		e, err := op.edit(r.v.vec)
+		if err != nil {
+			return err
+		}
+		r.v.applyEdit(e, false)
+		if r.v.history != nil {
+			r.v.history.add(e)
+		}
+		r.seq = op.Seq
+	}
+	return nil
+}
+
+//Sync brings the replica up to date with the leader serving ServeSync on rw, it loads a full snapshot
+//if the leader no longer has all the missing ops. ErrDiverged is returned if the replica does not have
+//the same hash as the leader afterwards
+func (r *Replica) Sync(rw io.ReadWriter) error {
+	if _, err := rw.Write(appendUint64(nil, r.seq)); err != nil {
+		return err
+	}
+	header := make([]byte, syncHeader)
+	if _, err := io.ReadFull(rw, header); err != nil {
+		return err
+	}
+	size := binary.BigEndian.Uint32(header[1+8+sha256.Size:])
+	if size > maxSyncPayload {
+		return errors.New("Sync frame too large")
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(rw, payload); err != nil {
+		return err
+	}
+	seq := binary.BigEndian.Uint64(header[1:])
+
+	switch header[0] {
+	case syncOps:
+		ops, err := DecodeOps(payload)
+		if err != nil {
+			return err
+		}
+		if err := r.Apply(ops); err != nil {
+			return err
+		}
+	case syncSnapshot:
+		if len(payload)%8 != 0 {
+			return errors.New("Invalid length")
+		}
+		s, err := decodeWords(make([]int, 0, len(payload)/8), payload)
+		if err != nil {
+			return err
+		}
+		r.v.replace(s)
+		r.seq = seq
+	default:
+		return errors.New("Invalid sync frame")
+	}
+
+	if r.seq != seq || r.v.Hash() != hex.EncodeToString(header[1+8:1+8+sha256.Size]) {
+		return ErrDiverged
+	}
+	return nil
+}
@@ -0,0 +1,220 @@
+package intvector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+	"math/rand"
+	"net"
+	"reflect"
+	"testing"
+)
+
+//mutate applies a random mutation to v
+func mutate(v *Intvector) {
+	switch rand.Intn(10) {
+	case 0:
+		v.Insert(rand.Intn(100), rand.Intn(100))
+	case 1:
+		v.Pop()
+	case 2:
+		v.Shift()
+	case 3:
+		v.Unshift(rand.Intn(100))
+	case 4:
+		if v.Size() > 0 {
+			v.Set(rand.Intn(v.Size()), rand.Intn(100))
+		}
+	case 5:
+		if v.Size() > 1 {
+			v.Swap(0, v.Size()-1)
+		}
+	case 6:
+		v.Reverse()
+	case 7:
+		v.RemoveAll(rand.Intn(100))
+	case 8:
+		if rand.Intn(5) == 0 {
+			v.Sort()
+		} else {
+			v.SortedPush(rand.Intn(100))
+		}
+	default:
+		v.Push(rand.Intn(100))
+	}
+}
+
+func TestReplicaApply(t *testing.T) {
+	var leader Intvector
+	leader.Insert(3, 1, 2)
+	log := NewOpLog(&leader, 1000)
+	defer log.Close()
+
+	var follower Intvector
+	r := NewReplica(&follower)
+	for i := 0; i < 500; i++ {
+		mutate(&leader)
+		if i%7 == 0 {
+			ops, ok := log.Since(r.Seq())
+			if !ok {
+				t.Fatal("ReplicaApply Test failed : want the ops to be kept")
+			}
+			//the ops go through their binary form like they would between processes
+			decoded, err := DecodeOps(EncodeOps(ops))
+			if err != nil || !reflect.DeepEqual(decoded, ops) {
+				t.Fatalf("ReplicaApply Test failed : ops do not round trip, %v", err)
+			}
+			if err := r.Apply(decoded); err != nil {
+				t.Fatalf("ReplicaApply Test failed : %v", err)
+			}
+			if follower.Hash() != leader.Hash() {
+				t.Fatalf("ReplicaApply Test failed : want %v got %v", leader.vec, follower.vec)
+			}
+		}
+	}
+
+	//ops delivered twice are skipped
+	ops, _ := log.Since(r.Seq() - 3)
+	if err := r.Apply(ops); err != nil || follower.Hash() != leader.Hash() {
+		t.Errorf("ReplicaApply Test failed : applying ops twice changed the replica, %v", err)
+	}
+}
+
+func TestReplicaGap(t *testing.T) {
+	var leader Intvector
+	log := NewOpLog(&leader, 4)
+	var follower Intvector
+	r := NewReplica(&follower)
+
+	leader.Push(1)
+	leader.Push(2)
+	ops, _ := log.Since(0)
+	r.Apply(ops[:1])
+
+	leader.Push(3)
+	missing, _ := log.Since(2)
+	hash := follower.Hash()
+	if err := r.Apply(missing); err != ErrGap {
+		t.Errorf("ReplicaGap Test failed : want ErrGap got %v", err)
+	}
+	if follower.Hash() != hash || r.Seq() != 1 {
+		t.Error("ReplicaGap Test failed : a gap changed the replica")
+	}
+
+	//the log no longer holds the ops the follower needs
+	for i := 0; i < 10; i++ {
+		leader.Push(i)
+	}
+	if _, ok := log.Since(r.Seq()); ok {
+		t.Error("ReplicaGap Test failed : want the follower to need a snapshot")
+	}
+}
+
+func TestReplicaSync(t *testing.T) {
+	leaderConn, followerConn := net.Pipe()
+	defer leaderConn.Close()
+	defer followerConn.Close()
+
+	var leader Intvector
+	log := NewOpLog(&leader, 20)
+	var follower Intvector
+	r := NewReplica(&follower)
+
+	//the leader mutates and serves in turns so the vector is only ever used by one goroutine
+	rounds := 30
+	done := make(chan error, 1)
+	go func() {
+		for i := 0; i < rounds; i++ {
+			//some rounds have more mutations than the log keeps
+			for j := rand.Intn(40); j > 0; j-- {
+				mutate(&leader)
+			}
+			if err := log.ServeSync(leaderConn); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+
+	for i := 0; i < rounds; i++ {
+		if err := r.Sync(followerConn); err != nil {
+			t.Fatalf("ReplicaSync Test failed : round %d %v", i, err)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("ReplicaSync Test failed : %v", err)
+	}
+	if r.Seq() != log.Seq() || follower.Hash() != leader.Hash() {
+		t.Error("ReplicaSync Test failed : the replica did not converge")
+	}
+}
+
+func TestReplicaDiverged(t *testing.T) {
+	leaderConn, followerConn := net.Pipe()
+	defer leaderConn.Close()
+	defer followerConn.Close()
+
+	var leader Intvector
+	log := NewOpLog(&leader, 10)
+	leader.Insert(1, 2, 3)
+
+	//a follower that was changed behind the back of the replica
+	var follower Intvector
+	r := NewReplica(&follower)
+	follower.Push(4)
+
+	go log.ServeSync(leaderConn)
+	if err := r.Sync(followerConn); err != ErrDiverged {
+		t.Errorf("ReplicaDiverged Test failed : want ErrDiverged got %v", err)
+	}
+}
+
+func TestReplicaInvalidOps(t *testing.T) {
+	var follower Intvector
+	r := NewReplica(&follower)
+	r.Apply([]Op{{Seq: 1, Kind: OpInsert, Values: []int{1, 2, 3}}})
+
+	//an op decoded from untrusted bytes with an index near the largest int must not overflow the bounds check
+	ops, err := DecodeOps(EncodeOps([]Op{{Seq: 2, Kind: OpRemove, Idx: math.MaxInt, Values: []int{1, 2}}}))
+	if err != nil {
+		t.Fatalf("ReplicaInvalidOps Test failed : DecodeOps returned error %s", err)
+	}
+	if err := r.Apply(ops); err != ErrDiverged {
+		t.Errorf("ReplicaInvalidOps Test failed : want ErrDiverged got %v", err)
+	}
+
+	//an op that does not fit in the middle of a batch leaves the ops before it unapplied as well
+	hash := follower.Hash()
+	batch := []Op{
+		{Seq: 2, Kind: OpSet, Idx: 0, Values: []int{10}},
+		{Seq: 3, Kind: OpRemove, Idx: 0, Values: []int{10, 2}},
+		{Seq: 4, Kind: OpSet, Idx: 1, Values: []int{5}},
+	}
+	if err := r.Apply(batch); err != ErrDiverged {
+		t.Errorf("ReplicaInvalidOps Test failed : want ErrDiverged got %v", err)
+	}
+	if follower.Hash() != hash || r.Seq() != 1 {
+		t.Error("ReplicaInvalidOps Test failed : a failed batch changed the replica")
+	}
+	if err := r.Apply(batch[:2]); err != nil || !reflect.DeepEqual(follower.vec, []int{3}) {
+		t.Errorf("ReplicaInvalidOps Test failed : want [3] got %v, %v", follower.vec, err)
+	}
+}
+
+func TestReplicaSyncTooLarge(t *testing.T) {
+	//a frame announcing a huge payload is refused before the payload is allocated
+	frame := make([]byte, syncHeader)
+	frame[0] = syncSnapshot
+	binary.BigEndian.PutUint32(frame[syncHeader-4:], math.MaxUint32)
+	conn := struct {
+		io.Reader
+		io.Writer
+	}{bytes.NewReader(frame), &bytes.Buffer{}}
+
+	var follower Intvector
+	if err := NewReplica(&follower).Sync(conn); err == nil {
+		t.Error("ReplicaSyncTooLarge Test failed : want an error for a payload above the limit")
+	}
+}
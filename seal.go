@@ -0,0 +1,181 @@
+package intvector
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+//SealKey is a secret used by SealTo and OpenFrom
+//ID is stored in the clear with every sealed vector so the right key can be found after a key rotation
+type SealKey struct {
+	ID     uint32
+	Secret []byte
+}
+
+//Keyring holds every key a sealed vector might have been sealed with
+type Keyring []SealKey
+
+var (
+	//ErrTampered is returned by OpenFrom when a sealed vector does not authenticate,
+	//either because it was modified or because the key is not the one it was sealed with
+	ErrTampered = errors.New("Authentication failed, the data was tampered with or the key is wrong")
+	//ErrUnknownKey is returned by OpenFrom when the keyring has no key with the ID of the sealed vector
+	ErrUnknownKey = errors.New("Unknown key ID")
+)
+
+//sealMagic starts every sealed vector
+var sealMagic = []byte("IVE1")
+
+//sealChunkWords is the number of elements encrypted together, it bounds the memory used while streaming
+const sealChunkWords = 8192
+
+//sealHeaderSize is the size of magic, key ID, chunk size and salt
+const sealHeaderSize = 4 + 4 + 4 + 16
+
+//SealTo encrypts and authenticates the serialized vector with AES-256-GCM and writes it to w.
+//Every call derives a fresh key from the secret and a random salt, so nonces are never reused.
+//The vector is split into chunks that are sealed one by one, the last one is marked so that
+//truncating the stream is detected as well
+func (v *Intvector) SealTo(w io.Writer, key SealKey) error {
+	header := make([]byte, sealHeaderSize)
+	copy(header, sealMagic)
+	binary.BigEndian.PutUint32(header[4:], key.ID)
+	binary.BigEndian.PutUint32(header[8:], sealChunkWords)
+	if _, err := io.ReadFull(rand.Reader, header[12:]); err != nil {
+		return err
+	}
+
+	aead, err := sealAEAD(key.Secret, header[12:])
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	var counter uint64
+	for i := 0; ; i += sealChunkWords {
+		end := i + sealChunkWords
+		last := end >= len(v.vec)
+		if last {
+			end = len(v.vec)
+		}
+
+		sealed := aead.Seal(nil, sealNonce(counter, last), appendWords(nil, v.vec[i:end]), header)
+		var size [4]byte
+		binary.BigEndian.PutUint32(size[:], uint32(len(sealed)))
+		if _, err := w.Write(append(size[:], sealed...)); err != nil {
+			return err
+		}
+		if last {
+			return nil
+		}
+		counter++
+	}
+}
+
+//OpenFrom reads a vector sealed by SealTo from r and replaces the contents of the vector with it.
+//The vector is only changed if the whole stream authenticates, otherwise ErrTampered is returned
+func (v *Intvector) OpenFrom(r io.Reader, keys Keyring) error {
+	header := make([]byte, sealHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.Equal(header[:4], sealMagic) {
+		return errors.New("Invalid header")
+	}
+
+	//the chunk size bounds the allocation of every chunk before it is authenticated, so it must not come from the stream
+	if binary.BigEndian.Uint32(header[8:]) != sealChunkWords {
+		return errors.New("Invalid header")
+	}
+	id := binary.BigEndian.Uint32(header[4:])
+	var secret []byte
+	for _, k := range keys {
+		if k.ID == id {
+			secret = k.Secret
+			break
+		}
+	}
+	if secret == nil {
+		return ErrUnknownKey
+	}
+
+	aead, err := sealAEAD(secret, header[12:])
+	if err != nil {
+		return err
+	}
+	maxSealed := uint64(sealChunkWords)*8 + uint64(aead.Overhead())
+
+	s := []int{}
+	var counter uint64
+	for {
+		var size [4]byte
+		if _, err := io.ReadFull(r, size[:]); err != nil {
+			//the last chunk is missing, the stream was truncated
+			return ErrTampered
+		}
+		n := binary.BigEndian.Uint32(size[:])
+		if uint64(n) > maxSealed {
+			return ErrTampered
+		}
+		sealed := make([]byte, n)
+		if _, err := io.ReadFull(r, sealed); err != nil {
+			return ErrTampered
+		}
+
+		//a chunk only opens with the nonce it was sealed with, this also tells whether it is the last one
+		last := false
+		plain, err := aead.Open(nil, sealNonce(counter, false), sealed, header)
+		if err != nil {
+			last = true
+			if plain, err = aead.Open(nil, sealNonce(counter, true), sealed, header); err != nil {
+				return ErrTampered
+			}
+		}
+		if len(plain)%8 != 0 {
+			return ErrTampered
+		}
+		if s, err = decodeWords(s, plain); err != nil {
+			return err
+		}
+		if last {
+			break
+		}
+		counter++
+	}
+
+	//replace the contents in a single step so that it is a single entry in the history
+	v.replace(s)
+	return nil
+}
+
+//sealAEAD derives the AES-256-GCM cipher of a single sealed vector from the secret and its salt
+func sealAEAD(secret []byte, salt []byte) (cipher.AEAD, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("Empty secret")
+	}
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("intvector seal"))
+	mac.Write(salt)
+
+	block, err := aes.NewCipher(mac.Sum(nil))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+//sealNonce returns the nonce of the chunk with the given counter, the last byte marks the last chunk
+func sealNonce(counter uint64, last bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce, counter)
+	if last {
+		nonce[11] = 1
+	}
+	return nonce
+}
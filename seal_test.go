@@ -0,0 +1,121 @@
+package intvector
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestSealTo(t *testing.T) {
+	keys := Keyring{
+		{ID: 1, Secret: []byte("an old secret that was rotated")},
+		{ID: 2, Secret: []byte("the current secret")},
+	}
+
+	for _, size := range []int{0, 1, sealChunkWords, sealChunkWords + 1, 2*sealChunkWords + 5} {
+		var s Intvector
+		for i := 0; i < size; i++ {
+			s.Push(i*31 - 1000)
+		}
+
+		for _, key := range keys {
+			var buf bytes.Buffer
+			if err := s.SealTo(&buf, key); err != nil {
+				t.Fatalf("SealTo Test failed : returned error %s", err)
+			}
+
+			var got Intvector
+			got.Push(99)
+			if err := got.OpenFrom(&buf, keys); err != nil {
+				t.Errorf("SealTo Test failed : OpenFrom returned error %s for %d elements with key %d", err, size, key.ID)
+				continue
+			}
+			if got.Hash() != s.Hash() {
+				t.Errorf("SealTo Test failed : %d elements did not round trip with key %d", size, key.ID)
+			}
+		}
+	}
+}
+
+func TestSealToNonce(t *testing.T) {
+	var s Intvector
+	s.Insert(1, 2, 3)
+	key := SealKey{ID: 7, Secret: []byte("secret")}
+
+	var a, b bytes.Buffer
+	s.SealTo(&a, key)
+	s.SealTo(&b, key)
+	if bytes.Equal(a.Bytes(), b.Bytes()) {
+		t.Error("SealTo Test failed : sealing the same vector twice gave the same bytes")
+	}
+}
+
+func TestOpenFrom(t *testing.T) {
+	var s Intvector
+	for i := 0; i < sealChunkWords+10; i++ {
+		s.Push(i)
+	}
+	key := SealKey{ID: 3, Secret: []byte("secret")}
+	var buf bytes.Buffer
+	s.SealTo(&buf, key)
+	sealed := buf.Bytes()
+
+	open := func(b []byte, keys Keyring) (*Intvector, error) {
+		var v Intvector
+		v.Insert(4, 5, 6)
+		err := v.OpenFrom(bytes.NewReader(b), keys)
+		if err != nil && v.Size() != 3 {
+			t.Errorf("OpenFrom Test failed : vector was changed by a failed OpenFrom")
+		}
+		return &v, err
+	}
+
+	//every single flipped bit must be detected, including the ones in the header
+	for _, pos := range []int{5, 12, sealHeaderSize + 2, sealHeaderSize + 20, len(sealed) / 2, len(sealed) - 1} {
+		tampered := append([]byte{}, sealed...)
+		tampered[pos] ^= 1
+		if _, err := open(tampered, Keyring{key}); err == nil {
+			t.Errorf("OpenFrom Test failed : flipped bit at byte %d was not detected", pos)
+		}
+	}
+
+	if _, err := open(sealed[:len(sealed)-10], Keyring{key}); err != ErrTampered {
+		t.Errorf("OpenFrom Test failed : want ErrTampered for truncated stream, got %v", err)
+	}
+
+	//dropping the whole last chunk must be detected as well
+	firstChunk := sealHeaderSize + 4 + 8*sealChunkWords + 16
+	if _, err := open(sealed[:firstChunk], Keyring{key}); err != ErrTampered {
+		t.Errorf("OpenFrom Test failed : want ErrTampered for missing last chunk, got %v", err)
+	}
+
+	if _, err := open(sealed, Keyring{{ID: 3, Secret: []byte("wrong")}}); err != ErrTampered {
+		t.Errorf("OpenFrom Test failed : want ErrTampered for wrong key, got %v", err)
+	}
+	if _, err := open(sealed, Keyring{{ID: 4, Secret: []byte("secret")}}); err != ErrUnknownKey {
+		t.Errorf("OpenFrom Test failed : want ErrUnknownKey, got %v", err)
+	}
+	//a forged chunk size is rejected before it can size an allocation
+	forged := append([]byte{}, sealed...)
+	binary.BigEndian.PutUint32(forged[8:], 1<<29)
+	binary.BigEndian.PutUint32(forged[sealHeaderSize:], 1<<31)
+	if _, err := open(forged, Keyring{key}); err == nil || err == ErrTampered {
+		t.Errorf("OpenFrom Test failed : want an invalid header error for a forged chunk size, got %v", err)
+	}
+
+	//opening replaces the contents in a single step of the history
+	v := Intvector{}
+	v.Insert(4, 5, 6)
+	v.EnableHistory(10)
+	if err := v.OpenFrom(bytes.NewReader(sealed), Keyring{key}); err != nil || v.Size() != s.Size() {
+		t.Fatalf("OpenFrom Test failed : returned error %v and size %d", err, v.Size())
+	}
+	v.Undo()
+	if v.Size() != 3 || v.CanUndo() {
+		t.Errorf("OpenFrom Test failed : want a single undo back to size 3 got size %d", v.Size())
+	}
+
+	if _, err := open(s.Serialized(), Keyring{key}); err == nil {
+		t.Error("OpenFrom Test failed : should return error for unsealed input")
+	}
+}
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+)
+
+//The protocol is the request and reply format of redis (RESP). A request is an array of bulk strings,
+//	*2\r\n$4\r\nHASH\r\n$1\r\na\r\n
+//or an inline command of words separated by spaces and ended by a newline, as typed into telnet.
+//Replies are simple strings (+OK), errors (-ERR ...), integers (:1), bulk strings ($3\r\nabc) and arrays (*2)
+
+//maxBulk is the largest bulk string accepted in a request
+const maxBulk = 64 << 20
+
+//maxArgs is the largest number of arguments accepted in a request
+const maxArgs = 1 << 20
+
+//maxLine is the longest inline command or header line accepted in a request, as in redis
+const maxLine = 64 << 10
+
+//errProtocol is returned for requests that are not valid RESP, the connection is closed after replying
+var errProtocol = errors.New("Protocol error")
+
+//readRequest reads the next request and returns its arguments
+func readRequest(r *bufio.Reader) ([]string, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 || line[0] != '*' {
+		return strings.Fields(line), nil
+	}
+
+	n, err := strconv.Atoi(line[1:])
+	if err != nil || n < 0 || n > maxArgs {
+		return nil, errProtocol
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := readLine(r)
+		if err != nil {
+			return nil, err
+		}
+		if len(line) == 0 || line[0] != '$' {
+			return nil, errProtocol
+		}
+		size, err := strconv.Atoi(line[1:])
+		if err != nil || size < 0 || size > maxBulk {
+			return nil, errProtocol
+		}
+		b := make([]byte, size+2)
+		if _, err := io.ReadFull(r, b); err != nil {
+			return nil, err
+		}
+		if b[size] != '\r' || b[size+1] != '\n' {
+			return nil, errProtocol
+		}
+		args[i] = string(b[:size])
+	}
+	return args, nil
+}
+
+//readLine reads a line ended by \r\n or \n and returns it without the line ending,
+//a line longer than maxLine is a protocol error so that a client without newlines cannot use up the memory
+func readLine(r *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if len(line)+len(chunk) > maxLine+2 {
+			return "", errProtocol
+		}
+		line = append(line, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				return "", io.ErrUnexpectedEOF
+			}
+			return "", err
+		}
+		return strings.TrimSuffix(string(line[:len(line)-1]), "\r"), nil
+	}
+}
+
+//writer writes replies
+type writer struct {
+	w *bufio.Writer
+}
+
+func (w writer) simple(s string) {
+	w.w.WriteString("+" + s + "\r\n")
+}
+
+func (w writer) err(s string) {
+	w.w.WriteString("-ERR " + s + "\r\n")
+}
+
+func (w writer) int(n int) {
+	w.w.WriteString(":" + strconv.Itoa(n) + "\r\n")
+}
+
+func (w writer) bulk(s string) {
+	w.w.WriteString("$" + strconv.Itoa(len(s)) + "\r\n" + s + "\r\n")
+}
+
+func (w writer) null() {
+	w.w.WriteString("$-1\r\n")
+}
+
+func (w writer) array(n int) {
+	w.w.WriteString("*" + strconv.Itoa(n) + "\r\n")
+}
+
+func (w writer) ints(s []int) {
+	w.array(len(s))
+	for _, n := range s {
+		w.int(n)
+	}
+}
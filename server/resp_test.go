@@ -0,0 +1,59 @@
+package server
+
+import (
+	"bufio"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadRequest(t *testing.T) {
+	cases := []struct {
+		in   string
+		want []string
+	}{
+		{"*2\r\n$4\r\nHASH\r\n$1\r\na\r\n", []string{"HASH", "a"}},
+		{"*1\r\n$0\r\n\r\n", []string{""}},
+		{"*2\r\n$4\r\nPUSH\r\n$4\r\na\r\nb\r\n", []string{"PUSH", "a\r\nb"}},
+		{"PUSH a  1\r\n", []string{"PUSH", "a", "1"}},
+		{"SIZE a\n", []string{"SIZE", "a"}},
+		{"\r\n", []string{}},
+	}
+	for _, c := range cases {
+		got, err := readRequest(bufio.NewReader(strings.NewReader(c.in)))
+		if err != nil || !reflect.DeepEqual(got, c.want) {
+			t.Errorf("ReadRequest Test failed : %q want %q got %q, %v", c.in, c.want, got, err)
+		}
+	}
+
+	for _, in := range []string{"*x\r\n", "*1\r\n:1\r\n", "*1\r\n$3\r\nabcd\r\n", "*-5\r\n"} {
+		if _, err := readRequest(bufio.NewReader(strings.NewReader(in))); err != errProtocol {
+			t.Errorf("ReadRequest Test failed : %q want a protocol error got %v", in, err)
+		}
+	}
+	if _, err := readRequest(bufio.NewReader(strings.NewReader("*1\r\n$3\r\nab"))); err == nil {
+		t.Error("ReadRequest Test failed : want an error for a truncated request")
+	}
+
+	//a line may be up to maxLine long, a client that never sends a newline is cut off after that
+	long := strings.Repeat("a", maxLine)
+	if got, err := readRequest(bufio.NewReader(strings.NewReader(long + "\r\n"))); err != nil || len(got) != 1 || got[0] != long {
+		t.Errorf("ReadRequest Test failed : want a line of %d bytes got %v", maxLine, err)
+	}
+	for _, in := range []io.Reader{endless{}, io.MultiReader(strings.NewReader("*1\r\n"), endless{})} {
+		if _, err := readRequest(bufio.NewReader(in)); err != errProtocol {
+			t.Errorf("ReadRequest Test failed : want a protocol error for a line without end got %v", err)
+		}
+	}
+}
+
+//endless is an io.Reader that never ends a line
+type endless struct{}
+
+func (endless) Read(b []byte) (int, error) {
+	for i := range b {
+		b[i] = 'a'
+	}
+	return len(b), nil
+}
@@ -0,0 +1,449 @@
+//Package server hosts named vectors and serves them over TCP with the redis protocol, so that tools
+//written in any language, and redis-cli itself, can read and update them
+package server
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ankitshah86/intvector"
+)
+
+//ErrServerClosed is returned by Serve after Shutdown has been called
+var ErrServerClosed = errors.New("Server closed")
+
+//Server hosts named vectors, they are created by the first command that adds to them.
+//Every command runs on its own, so a command never sees a vector halfway through another command
+type Server struct {
+	mu      sync.Mutex
+	vectors map[string]*intvector.Intvector
+
+	connMu    sync.Mutex
+	listeners map[net.Listener]bool
+	conns     map[net.Conn]bool
+	closing   bool
+	wg        sync.WaitGroup
+}
+
+//New returns a server without any vectors
+func New() *Server {
+	return &Server{
+		vectors:   make(map[string]*intvector.Intvector),
+		listeners: make(map[net.Listener]bool),
+		conns:     make(map[net.Conn]bool),
+	}
+}
+
+//Do calls f with the named vector, creating it if needed. No command runs while f does,
+//so f can read and change the vector but must not keep it
+func (s *Server) Do(name string, f func(v *intvector.Intvector)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f(s.vector(name, true))
+}
+
+//vector returns the named vector, a missing vector is created if create is true
+//and is an empty vector that is not stored otherwise
+func (s *Server) vector(name string, create bool) *intvector.Intvector {
+	v, ok := s.vectors[name]
+	if !ok {
+		v = &intvector.Intvector{}
+		if create {
+			s.vectors[name] = v
+		}
+	}
+	return v
+}
+
+//ListenAndServe listens on the TCP address and serves connections until Shutdown is called
+func (s *Server) ListenAndServe(addr string) error {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(l)
+}
+
+//Serve accepts connections on l and serves them until Shutdown is called, it closes l when it returns
+func (s *Server) Serve(l net.Listener) error {
+	s.connMu.Lock()
+	if s.closing {
+		s.connMu.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	s.listeners[l] = true
+	s.connMu.Unlock()
+	defer func() {
+		s.connMu.Lock()
+		delete(s.listeners, l)
+		s.connMu.Unlock()
+		l.Close()
+	}()
+
+	for {
+		c, err := l.Accept()
+		if err != nil {
+			s.connMu.Lock()
+			closing := s.closing
+			s.connMu.Unlock()
+			if closing {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		s.connMu.Lock()
+		if s.closing {
+			s.connMu.Unlock()
+			c.Close()
+			return ErrServerClosed
+		}
+		s.conns[c] = true
+		s.wg.Add(1)
+		s.connMu.Unlock()
+		go s.serveConn(c)
+	}
+}
+
+//Shutdown stops accepting connections and lets every connection finish the commands it has already received,
+//then closes it. It returns once all connections are closed, or closes them at once when ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.connMu.Lock()
+	s.closing = true
+	for l := range s.listeners {
+		l.Close()
+	}
+	//a connection waiting for its next command stops reading, commands that are already buffered still run
+	for c := range s.conns {
+		c.SetReadDeadline(time.Now())
+	}
+	s.connMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.connMu.Lock()
+		for c := range s.conns {
+			c.Close()
+		}
+		s.connMu.Unlock()
+		return ctx.Err()
+	}
+}
+
+//serveConn runs the commands of a connection. Replies are only flushed when no further command is buffered,
+//so a client that pipelines its commands gets their replies in as few writes as possible
+func (s *Server) serveConn(c net.Conn) {
+	defer func() {
+		c.Close()
+		s.connMu.Lock()
+		delete(s.conns, c)
+		s.connMu.Unlock()
+		s.wg.Done()
+	}()
+
+	r := bufio.NewReader(c)
+	bw := bufio.NewWriter(c)
+	w := writer{w: bw}
+	for {
+		args, err := readRequest(r)
+		if err != nil {
+			if err == errProtocol {
+				w.err("Protocol error")
+			}
+			bw.Flush()
+			return
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		quit := s.exec(w, args)
+		if quit || r.Buffered() == 0 {
+			if err := bw.Flush(); err != nil {
+				return
+			}
+		}
+		if quit {
+			return
+		}
+	}
+}
+
+//command describes a command that works on a single vector, the first argument is the name of the vector
+//and the others are integers
+type command struct {
+	//min and max is the number of integer arguments, max is -1 for any number
+	min, max int
+	//create is true for commands that create a missing vector
+	create bool
+	run    func(w writer, v *intvector.Intvector, args []int)
+}
+
+//okOrErr replies +OK or the error
+func okOrErr(w writer, err error) {
+	if err != nil {
+		w.err(err.Error())
+		return
+	}
+	w.simple("OK")
+}
+
+//intOrNull replies with n or a null reply for an error
+func intOrNull(w writer, n int, err error) {
+	if err != nil {
+		w.null()
+		return
+	}
+	w.int(n)
+}
+
+//boolInt replies 1 for true and 0 for false
+func boolInt(w writer, b bool) {
+	if b {
+		w.int(1)
+	} else {
+		w.int(0)
+	}
+}
+
+//float replies with f as a bulk string, like redis does for floating point numbers
+func float(w writer, f float64) {
+	w.bulk(strconv.FormatFloat(f, 'g', -1, 64))
+}
+
+//elements returns the elements of v from i to j
+func elements(v *intvector.Intvector, i, j int) []int {
+	s := make([]int, 0, j-i)
+	for ; i < j; i++ {
+		n, _ := v.At(i)
+		s = append(s, n)
+	}
+	return s
+}
+
+var commands = map[string]command{
+	"PUSH": {1, -1, true, func(w writer, v *intvector.Intvector, args []int) {
+		v.Insert(args...)
+		w.int(v.Size())
+	}},
+	"UNSHIFT": {1, 1, true, func(w writer, v *intvector.Intvector, args []int) {
+		v.Unshift(args[0])
+		w.int(v.Size())
+	}},
+	"SORTEDPUSH": {1, 1, true, func(w writer, v *intvector.Intvector, args []int) {
+		v.SortedPush(args[0])
+		w.int(v.Size())
+	}},
+	"UNIQUEPUSH": {1, 1, true, func(w writer, v *intvector.Intvector, args []int) {
+		boolInt(w, v.UniquePush(args[0]))
+	}},
+	"POP": {0, 0, false, func(w writer, v *intvector.Intvector, args []int) {
+		n, err := v.Pop()
+		intOrNull(w, n, err)
+	}},
+	"SHIFT": {0, 0, false, func(w writer, v *intvector.Intvector, args []int) {
+		n, err := v.Shift()
+		intOrNull(w, n, err)
+	}},
+	"AT": {1, 1, false, func(w writer, v *intvector.Intvector, args []int) {
+		n, err := v.At(args[0])
+		if err != nil {
+			w.err(err.Error())
+			return
+		}
+		w.int(n)
+	}},
+	"SET": {2, 2, false, func(w writer, v *intvector.Intvector, args []int) {
+		okOrErr(w, v.Set(args[0], args[1]))
+	}},
+	"SWAP": {2, 2, false, func(w writer, v *intvector.Intvector, args []int) {
+		okOrErr(w, v.Swap(args[0], args[1]))
+	}},
+	"REMOVEAT": {1, 1, false, func(w writer, v *intvector.Intvector, args []int) {
+		okOrErr(w, v.RemoveAt(args[0]))
+	}},
+	"REMOVEFIRST": {1, 1, false, func(w writer, v *intvector.Intvector, args []int) {
+		boolInt(w, v.RemoveFirstOf(args[0]))
+	}},
+	"REMOVEALL": {1, 1, false, func(w writer, v *intvector.Intvector, args []int) {
+		w.int(v.RemoveAll(args[0]))
+	}},
+	"CLEAR": {0, 0, false, func(w writer, v *intvector.Intvector, args []int) {
+		v.Clear()
+		w.simple("OK")
+	}},
+	"SORT": {0, 0, false, func(w writer, v *intvector.Intvector, args []int) {
+		v.Sort()
+		w.simple("OK")
+	}},
+	"REVERSE": {0, 0, false, func(w writer, v *intvector.Intvector, args []int) {
+		v.Reverse()
+		w.simple("OK")
+	}},
+	"UNIQUE": {0, 0, false, func(w writer, v *intvector.Intvector, args []int) {
+		v.MakeUnique()
+		w.simple("OK")
+	}},
+	"SCALE": {1, 1, false, func(w writer, v *intvector.Intvector, args []int) {
+		v.ScaleBy(args[0])
+		w.simple("OK")
+	}},
+	"SIZE": {0, 0, false, func(w writer, v *intvector.Intvector, args []int) {
+		w.int(v.Size())
+	}},
+	"FIRST": {0, 0, false, func(w writer, v *intvector.Intvector, args []int) {
+		n, err := v.First()
+		intOrNull(w, n, err)
+	}},
+	"LAST": {0, 0, false, func(w writer, v *intvector.Intvector, args []int) {
+		n, err := v.Last()
+		intOrNull(w, n, err)
+	}},
+	"SEARCH": {1, 1, false, func(w writer, v *intvector.Intvector, args []int) {
+		w.int(v.Search(args[0]))
+	}},
+	"SEARCHALL": {1, 1, false, func(w writer, v *intvector.Intvector, args []int) {
+		w.ints(v.SearchAll(args[0]))
+	}},
+	"COUNT": {1, 1, false, func(w writer, v *intvector.Intvector, args []int) {
+		w.int(v.CountInstancesOf(args[0]))
+	}},
+	"MIN": {0, 0, false, func(w writer, v *intvector.Intvector, args []int) {
+		n, idx := v.Min()
+		w.ints([]int{n, idx})
+	}},
+	"MAX": {0, 0, false, func(w writer, v *intvector.Intvector, args []int) {
+		n, idx := v.Max()
+		w.ints([]int{n, idx})
+	}},
+	"MEAN": {0, 0, false, func(w writer, v *intvector.Intvector, args []int) {
+		float(w, v.Mean())
+	}},
+	"MEDIAN": {0, 0, false, func(w writer, v *intvector.Intvector, args []int) {
+		float(w, v.Median())
+	}},
+	"MODE": {0, 0, false, func(w writer, v *intvector.Intvector, args []int) {
+		n, err := v.Mode()
+		if err != nil {
+			w.err(err.Error())
+			return
+		}
+		w.int(n)
+	}},
+	"ISSORTED": {0, 0, false, func(w writer, v *intvector.Intvector, args []int) {
+		boolInt(w, v.IsSorted())
+	}},
+	"RANGE": {0, 2, false, func(w writer, v *intvector.Intvector, args []int) {
+		i, j := 0, v.Size()
+		if len(args) > 0 {
+			i = args[0]
+		}
+		if len(args) > 1 {
+			j = args[1]
+		}
+		if i < 0 || j > v.Size() || i > j {
+			w.err("Index out of bounds")
+			return
+		}
+		w.ints(elements(v, i, j))
+	}},
+	"HASH": {0, 0, false, func(w writer, v *intvector.Intvector, args []int) {
+		w.bulk(v.Hash())
+	}},
+	"DUMP": {0, 0, false, func(w writer, v *intvector.Intvector, args []int) {
+		w.bulk(string(v.Serialized()))
+	}},
+}
+
+//exec runs a single request and returns true if the connection should be closed
+func (s *Server) exec(w writer, args []string) bool {
+	name := strings.ToUpper(args[0])
+	args = args[1:]
+
+	switch name {
+	case "PING":
+		if len(args) > 0 {
+			w.bulk(args[0])
+		} else {
+			w.simple("PONG")
+		}
+		return false
+	case "QUIT":
+		w.simple("OK")
+		return true
+	case "COMMAND":
+		//redis-cli asks for the command docs when it starts, an empty reply makes it fall back to plain input
+		w.array(0)
+		return false
+	case "KEYS":
+		s.mu.Lock()
+		names := make([]string, 0, len(s.vectors))
+		for name := range s.vectors {
+			names = append(names, name)
+		}
+		s.mu.Unlock()
+		sort.Strings(names)
+		w.array(len(names))
+		for _, name := range names {
+			w.bulk(name)
+		}
+		return false
+	case "DEL", "EXISTS":
+		if len(args) == 0 {
+			w.err("wrong number of arguments for '" + strings.ToLower(name) + "' command")
+			return false
+		}
+		count := 0
+		s.mu.Lock()
+		for _, key := range args {
+			if _, ok := s.vectors[key]; ok {
+				count++
+				if name == "DEL" {
+					delete(s.vectors, key)
+				}
+			}
+		}
+		s.mu.Unlock()
+		w.int(count)
+		return false
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		w.err("unknown command '" + strings.ToLower(name) + "'")
+		return false
+	}
+	if len(args) < 1+cmd.min || cmd.max >= 0 && len(args) > 1+cmd.max {
+		w.err("wrong number of arguments for '" + strings.ToLower(name) + "' command")
+		return false
+	}
+	nums := make([]int, len(args)-1)
+	for i, arg := range args[1:] {
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			w.err("value is not an integer or out of range")
+			return false
+		}
+		nums[i] = n
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cmd.run(w, s.vector(args[0], cmd.create), nums)
+	return false
+}
@@ -0,0 +1,210 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ankitshah86/intvector"
+)
+
+//client is a minimal redis client for the tests
+type client struct {
+	c net.Conn
+	r *bufio.Reader
+}
+
+//start serves a new server on a loopback listener
+func start(t *testing.T) (*Server, string, chan error) {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := New()
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Serve(l)
+	}()
+	return s, l.Addr().String(), done
+}
+
+func dial(t *testing.T, addr string) *client {
+	t.Helper()
+	c, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return &client{c: c, r: bufio.NewReader(c)}
+}
+
+//encode returns the request for the arguments as an array of bulk strings
+func encode(args ...string) string {
+	s := "*" + strconv.Itoa(len(args)) + "\r\n"
+	for _, arg := range args {
+		s += "$" + strconv.Itoa(len(arg)) + "\r\n" + arg + "\r\n"
+	}
+	return s
+}
+
+//reply reads a reply and returns it as text, arrays are joined with spaces and null is "(nil)"
+func (c *client) reply(t *testing.T) string {
+	t.Helper()
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	switch line[0] {
+	case '$':
+		n, _ := strconv.Atoi(line[1:])
+		if n < 0 {
+			return "(nil)"
+		}
+		b := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, b); err != nil {
+			t.Fatal(err)
+		}
+		return string(b[:n])
+	case '*':
+		n, _ := strconv.Atoi(line[1:])
+		parts := make([]string, n)
+		for i := range parts {
+			parts[i] = c.reply(t)
+		}
+		return strings.Join(parts, " ")
+	}
+	return line
+}
+
+func (c *client) do(t *testing.T, args ...string) string {
+	t.Helper()
+	if _, err := c.c.Write([]byte(encode(args...))); err != nil {
+		t.Fatal(err)
+	}
+	return c.reply(t)
+}
+
+func TestCommands(t *testing.T) {
+	s, addr, _ := start(t)
+	defer s.Shutdown(context.Background())
+	c := dial(t, addr)
+
+	steps := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"PING"}, "+PONG"},
+		{[]string{"push", "a", "5", "3", "8"}, ":3"},
+		{[]string{"UNSHIFT", "a", "1"}, ":4"},
+		{[]string{"AT", "a", "2"}, ":3"},
+		{[]string{"AT", "a", "9"}, "-ERR Index out of bounds"},
+		{[]string{"SET", "a", "0", "9"}, "+OK"},
+		{[]string{"SORT", "a"}, "+OK"},
+		{[]string{"RANGE", "a"}, ":3 :5 :8 :9"},
+		{[]string{"RANGE", "a", "1", "3"}, ":5 :8"},
+		{[]string{"MEDIAN", "a"}, "6.5"},
+		{[]string{"MIN", "a"}, ":3 :0"},
+		{[]string{"POP", "a"}, ":9"},
+		{[]string{"SHIFT", "a"}, ":3"},
+		{[]string{"SIZE", "a"}, ":2"},
+		{[]string{"POP", "missing"}, "(nil)"},
+		{[]string{"EXISTS", "a", "missing"}, ":1"},
+		{[]string{"KEYS"}, "a"},
+		{[]string{"PUSH", "a", "x"}, "-ERR value is not an integer or out of range"},
+		{[]string{"PUSH", "a"}, "-ERR wrong number of arguments for 'push' command"},
+		{[]string{"NOPE", "a"}, "-ERR unknown command 'nope'"},
+		{[]string{"DEL", "a"}, ":1"},
+		{[]string{"SIZE", "a"}, ":0"},
+	}
+	for _, step := range steps {
+		if got := c.do(t, step.args...); got != step.want {
+			t.Errorf("Commands Test failed : %v want %q got %q", step.args, step.want, got)
+		}
+	}
+
+	var want string
+	s.Do("b", func(v *intvector.Intvector) {
+		v.Insert(1, 2, 3)
+		want = v.Hash()
+	})
+	if got := c.do(t, "HASH", "b"); got != want {
+		t.Errorf("Commands Test failed : want hash %s got %s", want, got)
+	}
+}
+
+func TestInline(t *testing.T) {
+	s, addr, _ := start(t)
+	defer s.Shutdown(context.Background())
+	c := dial(t, addr)
+
+	c.c.Write([]byte("PUSH a 1 2\r\nsize a\n"))
+	if got := c.reply(t); got != ":2" {
+		t.Errorf("Inline Test failed : want :2 got %q", got)
+	}
+	if got := c.reply(t); got != ":2" {
+		t.Errorf("Inline Test failed : want :2 got %q", got)
+	}
+}
+
+func TestPipelining(t *testing.T) {
+	s, addr, _ := start(t)
+	defer s.Shutdown(context.Background())
+	c := dial(t, addr)
+
+	//all requests are written before any reply is read
+	n := 1000
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		b.WriteString(encode("PUSH", "p", strconv.Itoa(i)))
+	}
+	b.WriteString(encode("SIZE", "p"))
+	if _, err := c.c.Write([]byte(b.String())); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if got, want := c.reply(t), ":"+strconv.Itoa(i+1); got != want {
+			t.Fatalf("Pipelining Test failed : want %s got %s", want, got)
+		}
+	}
+	if got := c.reply(t); got != ":1000" {
+		t.Errorf("Pipelining Test failed : want :1000 got %s", got)
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	s, addr, done := start(t)
+	c := dial(t, addr)
+	if got := c.do(t, "PUSH", "a", "1"); got != ":1" {
+		t.Fatalf("Shutdown Test failed : want :1 got %s", got)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := s.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown Test failed : %v", err)
+	}
+	if err := <-done; err != ErrServerClosed {
+		t.Errorf("Shutdown Test failed : want ErrServerClosed got %v", err)
+	}
+	//the idle connection was closed
+	if _, err := c.r.ReadByte(); err == nil {
+		t.Error("Shutdown Test failed : want the connection closed")
+	}
+	if _, err := net.Dial("tcp", addr); err == nil {
+		t.Error("Shutdown Test failed : want no new connections")
+	}
+
+	//the vectors are still there for the host
+	s.Do("a", func(v *intvector.Intvector) {
+		if v.Size() != 1 {
+			t.Errorf("Shutdown Test failed : want 1 element got %d", v.Size())
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package intvector
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+)
+
+//ErrBadSignature is returned when a signature does not match the vector or the public key
+var ErrBadSignature = errors.New("Invalid signature")
+
+//signDomain starts every signed message, so that a signature cannot be passed off as one of another protocol
+var signDomain = []byte("intvector-sig-v1")
+
+//signedMessage returns what is actually signed: the domain, the key ID prefixed by its length and the raw
+//sha256 digest that Hash returns in hex. Binding the key ID means an envelope cannot be relabelled
+func (v *Intvector) signedMessage(keyID string) []byte {
+	digest := sha256.Sum256(v.Serialized())
+	msg := append([]byte{}, signDomain...)
+	msg = appendWord(msg, len(keyID))
+	msg = append(msg, keyID...)
+	return append(msg, digest[:]...)
+}
+
+//Sign returns a detached ed25519 signature over the sha256 digest that Hash returns in hex
+func (v *Intvector) Sign(priv ed25519.PrivateKey) []byte {
+	return ed25519.Sign(priv, v.signedMessage(""))
+}
+
+//Verify returns true if sig is a signature made by Sign for the current contents of the vector
+func (v *Intvector) Verify(pub ed25519.PublicKey, sig []byte) bool {
+	return v.verify("", pub, sig)
+}
+
+//verify checks a signature made for the given key ID
+func (v *Intvector) verify(keyID string, pub ed25519.PublicKey, sig []byte) bool {
+	if len(pub) != ed25519.PublicKeySize {
+		return false
+	}
+	return ed25519.Verify(pub, v.signedMessage(keyID), sig)
+}
+
+//Envelope bundles a serialized vector with the ID of the key that signed it and the signature
+//Everything needed to check it is inside apart from the public key, so it can be verified offline
+type Envelope struct {
+	KeyID     string
+	Payload   []byte
+	Signature []byte
+}
+
+//envelopeMagic starts every serialized envelope
+var envelopeMagic = []byte("IVSG")
+
+//SignedEnvelope signs the vector and returns it in an envelope, the signature also covers the key ID
+func (v *Intvector) SignedEnvelope(keyID string, priv ed25519.PrivateKey) *Envelope {
+	return &Envelope{
+		KeyID:     keyID,
+		Payload:   v.Serialized(),
+		Signature: ed25519.Sign(priv, v.signedMessage(keyID)),
+	}
+}
+
+//Open verifies the envelope with the given public key and returns the vector inside
+func (e *Envelope) Open(pub ed25519.PublicKey) (*Intvector, error) {
+	var v Intvector
+	if len(e.Payload) > 0 {
+		if err := v.DeserializeFrom(e.Payload, false); err != nil {
+			return nil, err
+		}
+	}
+	if !v.verify(e.KeyID, pub, e.Signature) {
+		return nil, ErrBadSignature
+	}
+	return &v, nil
+}
+
+//Serialized returns the envelope as a slice of bytes
+//The layout is the magic, the key ID, the payload and the signature, each prefixed by its length as an 8 byte big endian word
+func (e *Envelope) Serialized() []byte {
+	b := append([]byte{}, envelopeMagic...)
+	for _, field := range [][]byte{[]byte(e.KeyID), e.Payload, e.Signature} {
+		b = appendWord(b, len(field))
+		b = append(b, field...)
+	}
+	return b
+}
+
+//DeserializeFrom replaces the envelope with the one encoded in b by Serialized, it does not verify the signature
+func (e *Envelope) DeserializeFrom(b []byte) error {
+	if !bytes.HasPrefix(b, envelopeMagic) {
+		return errors.New("Invalid header")
+	}
+	b = b[len(envelopeMagic):]
+
+	fields := make([][]byte, 3)
+	for i := range fields {
+		if len(b) < 8 {
+			return errors.New("Invalid length")
+		}
+		n := binary.BigEndian.Uint64(b)
+		b = b[8:]
+		if n > uint64(len(b)) {
+			return errors.New("Invalid length")
+		}
+		fields[i] = append([]byte{}, b[:n]...)
+		b = b[n:]
+	}
+	if len(b) != 0 {
+		return errors.New("Invalid length")
+	}
+
+	e.KeyID, e.Payload, e.Signature = string(fields[0]), fields[1], fields[2]
+	return nil
+}
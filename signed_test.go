@@ -0,0 +1,84 @@
+package intvector
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"testing"
+)
+
+func TestSign(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	otherPub, _, _ := ed25519.GenerateKey(nil)
+
+	var s Intvector
+	s.Insert(1, -2, 3)
+	sig := s.Sign(priv)
+
+	if !s.Verify(pub, sig) {
+		t.Error("Sign Test failed : valid signature was rejected")
+	}
+	if s.Verify(otherPub, sig) {
+		t.Error("Sign Test failed : signature was accepted for another key")
+	}
+	if s.Verify(pub[:10], sig) {
+		t.Error("Sign Test failed : signature was accepted for a truncated key")
+	}
+
+	//a signature over the bare digest, as another protocol might make, is not accepted
+	digest := sha256.Sum256(s.Serialized())
+	if s.Verify(pub, ed25519.Sign(priv, digest[:])) {
+		t.Error("Sign Test failed : signature over the bare digest was accepted")
+	}
+
+	s.Push(4)
+	if s.Verify(pub, sig) {
+		t.Error("Sign Test failed : signature was accepted after the vector changed")
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+
+	var s Intvector
+	s.Insert(10, 20, 30)
+	b := s.SignedEnvelope("service-a/2026", priv).Serialized()
+
+	var e Envelope
+	if err := e.DeserializeFrom(b); err != nil {
+		t.Fatalf("Envelope Test failed : DeserializeFrom returned error %s", err)
+	}
+	if e.KeyID != "service-a/2026" {
+		t.Errorf("Envelope Test failed : want key ID %s got %s", "service-a/2026", e.KeyID)
+	}
+
+	got, err := e.Open(pub)
+	if err != nil {
+		t.Fatalf("Envelope Test failed : Open returned error %s", err)
+	}
+	if got.Hash() != s.Hash() {
+		t.Error("Envelope Test failed : opened vector differs from the signed one")
+	}
+
+	//the signature is bound to the key ID, so the envelope cannot be relabelled
+	e.KeyID = "service-b/2026"
+	if _, err := e.Open(pub); err != ErrBadSignature {
+		t.Errorf("Envelope Test failed : want ErrBadSignature for a changed key ID, got %v", err)
+	}
+	e.KeyID = "service-a/2026"
+
+	//changing the payload must be detected
+	e.Payload[len(e.Payload)-1]++
+	if _, err := e.Open(pub); err != ErrBadSignature {
+		t.Errorf("Envelope Test failed : want ErrBadSignature for tampered payload, got %v", err)
+	}
+
+	if err := e.DeserializeFrom(b[:len(b)-1]); err == nil {
+		t.Error("Envelope Test failed : should return error for truncated input")
+	}
+
+	//an empty vector can be signed too
+	var empty Intvector
+	if _, err := empty.SignedEnvelope("k", priv).Open(pub); err != nil {
+		t.Errorf("Envelope Test failed : Open returned error %s for empty vector", err)
+	}
+}
@@ -0,0 +1,127 @@
+package intvector
+
+import (
+	"errors"
+	"math/bits"
+)
+
+//SparseTable answers range minimum and range maximum queries on a static vector in O(1)
+//It is built once in O(n log n) and does not follow later changes to the vector
+type SparseTable struct {
+	n int
+	//min[k][i] and max[k][i] hold the minimum and maximum of the range [i, i+2^k)
+	min [][]int
+	max [][]int
+}
+
+//NewSparseTable builds a sparse table over the current elements of the given vector
+func NewSparseTable(v *Intvector) *SparseTable {
+	n := len(v.vec)
+	t := &SparseTable{n: n}
+	if n == 0 {
+		return t
+	}
+
+	levels := bits.Len(uint(n))
+	t.min = make([][]int, levels)
+	t.max = make([][]int, levels)
+	t.min[0] = append([]int{}, v.vec...)
+	t.max[0] = append([]int{}, v.vec...)
+
+	for k := 1; k < levels; k++ {
+		half := 1 << (k - 1)
+		size := n - (1 << k) + 1
+		t.min[k] = make([]int, size)
+		t.max[k] = make([]int, size)
+		for i := 0; i < size; i++ {
+			t.min[k][i] = t.min[k-1][i]
+			if t.min[k-1][i+half] < t.min[k][i] {
+				t.min[k][i] = t.min[k-1][i+half]
+			}
+			t.max[k][i] = t.max[k-1][i]
+			if t.max[k-1][i+half] > t.max[k][i] {
+				t.max[k][i] = t.max[k-1][i+half]
+			}
+		}
+	}
+	return t
+}
+
+//Size returns the number of elements covered by the table
+func (t *SparseTable) Size() int {
+	return t.n
+}
+
+//Min returns the minimum of the elements in the range [i, j), the range must not be empty
+func (t *SparseTable) Min(i int, j int) (int, error) {
+	if err := checkNonEmptyRange(i, j, t.n); err != nil {
+		return 0, err
+	}
+
+	//two overlapping power of two windows cover the whole range
+	k := bits.Len(uint(j-i)) - 1
+	a, b := t.min[k][i], t.min[k][j-(1<<k)]
+	if b < a {
+		return b, nil
+	}
+	return a, nil
+}
+
+//Max returns the maximum of the elements in the range [i, j), the range must not be empty
+func (t *SparseTable) Max(i int, j int) (int, error) {
+	if err := checkNonEmptyRange(i, j, t.n); err != nil {
+		return 0, err
+	}
+
+	k := bits.Len(uint(j-i)) - 1
+	a, b := t.max[k][i], t.max[k][j-(1<<k)]
+	if b > a {
+		return b, nil
+	}
+	return a, nil
+}
+
+//Serialized returns the table as a slice of bytes so it can be stored and loaded without being rebuilt
+//The layout is the element count followed by the min and then the max table of every level, all as 8 byte big endian words
+func (t *SparseTable) Serialized() []byte {
+	b := appendWord(nil, t.n)
+	for _, level := range t.min {
+		b = appendWords(b, level)
+	}
+	for _, level := range t.max {
+		b = appendWords(b, level)
+	}
+	return b
+}
+
+//DeserializeFrom replaces the table with the one encoded in b by Serialized
+func (t *SparseTable) DeserializeFrom(b []byte) error {
+	r := wordReader{b: b}
+	n, err := r.word()
+	if err != nil {
+		return err
+	}
+	if n < 0 || n > len(b)/8 {
+		return errors.New("Invalid element count")
+	}
+
+	tmp := SparseTable{n: n}
+	if n > 0 {
+		levels := bits.Len(uint(n))
+		tmp.min = make([][]int, levels)
+		tmp.max = make([][]int, levels)
+		for _, table := range [][][]int{tmp.min, tmp.max} {
+			for k := range table {
+				if table[k], err = r.words(n - (1 << k) + 1); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	if !r.done() {
+		return errors.New("Invalid length")
+	}
+
+	*t = tmp
+	return nil
+}
@@ -0,0 +1,72 @@
+package intvector
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestSparseTable(t *testing.T) {
+	var s Intvector
+	for i := 0; i < 53; i++ {
+		s.Push(rand.Intn(200) - 100)
+	}
+	table := NewSparseTable(&s)
+
+	for i := 0; i < s.Size(); i++ {
+		for j := i + 1; j <= s.Size(); j++ {
+			want := bruteMin(s.vec, i, j)
+			got, err := table.Min(i, j)
+			if err != nil || want != got {
+				t.Errorf("SparseTable Min Test failed : want %d got %d (err %v) for range [%d, %d)", want, got, err, i, j)
+			}
+
+			want = bruteMax(s.vec, i, j)
+			got, err = table.Max(i, j)
+			if err != nil || want != got {
+				t.Errorf("SparseTable Max Test failed : want %d got %d (err %v) for range [%d, %d)", want, got, err, i, j)
+			}
+		}
+	}
+
+	if _, err := table.Min(3, 3); err == nil {
+		t.Error("SparseTable Test failed : Min should return error for empty range")
+	}
+	if _, err := table.Max(0, s.Size()+1); err == nil {
+		t.Error("SparseTable Test failed : Max should return error for out of range index")
+	}
+}
+
+func TestSparseTableSerialized(t *testing.T) {
+	for _, size := range []int{0, 1, 2, 7, 64, 100} {
+		var s Intvector
+		for i := 0; i < size; i++ {
+			s.Push(rand.Intn(1000) - 500)
+		}
+		table := NewSparseTable(&s)
+
+		var loaded SparseTable
+		if err := loaded.DeserializeFrom(table.Serialized()); err != nil {
+			t.Errorf("SparseTable Serialized Test failed : DeserializeFrom returned error %s for size %d", err, size)
+			continue
+		}
+		if loaded.Size() != size {
+			t.Errorf("SparseTable Serialized Test failed : want size %d got %d", size, loaded.Size())
+		}
+		for i := 0; i < size; i++ {
+			want, _ := table.Min(i, size)
+			got, _ := loaded.Min(i, size)
+			if want != got {
+				t.Errorf("SparseTable Serialized Test failed : want min %d got %d for range [%d, %d)", want, got, i, size)
+			}
+		}
+	}
+
+	var loaded SparseTable
+	b := NewSparseTable(&Intvector{vec: []int{1, 2, 3}}).Serialized()
+	if err := loaded.DeserializeFrom(b[:len(b)-8]); err == nil {
+		t.Error("SparseTable Serialized Test failed : should return error for truncated input")
+	}
+	if err := loaded.DeserializeFrom(append(b, 0, 0, 0, 0, 0, 0, 0, 0)); err == nil {
+		t.Error("SparseTable Serialized Test failed : should return error for trailing bytes")
+	}
+}
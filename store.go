@@ -0,0 +1,182 @@
+package intvector
+
+import (
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+//ErrNotFound is returned by Store.Get and Store.Delete for a hash that is not in the store
+var ErrNotFound = errors.New("Vector not found")
+
+//Store is a content-addressable store of vectors on the local disk.
+//Every vector is saved in the layout of Serialized under its Hash, in objects/<first two hex digits>/<hash>,
+//so identical vectors are only stored once
+type Store struct {
+	dir string
+}
+
+//OpenStore opens the store in dir, creating it if needed
+func OpenStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(filepath.Join(dir, "objects"), 0755); err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+//path returns the file of the given hash, after making sure the hash can not escape the store
+func (s *Store) path(hash string) (string, error) {
+	if b, err := hex.DecodeString(hash); err != nil || len(b) != 32 || hex.EncodeToString(b) != hash {
+		return "", errors.New("Invalid hash " + hash)
+	}
+	return filepath.Join(s.dir, "objects", hash[:2], hash), nil
+}
+
+//Put saves the vector and returns its hash, nothing is written if the store already has it
+func (s *Store) Put(v *Intvector) (string, error) {
+	hash := v.Hash()
+	p, _ := s.path(hash)
+	if _, err := os.Stat(p); err == nil {
+		return hash, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return "", err
+	}
+	if err := writeFileAtomic(p, v.Serialized()); err != nil {
+		//another Put of the same vector may have won the race, which is just as good
+		if s.Has(hash) {
+			return hash, nil
+		}
+		return "", err
+	}
+	return hash, nil
+}
+
+//Has returns true if the store has a vector with the given hash
+func (s *Store) Has(hash string) bool {
+	p, err := s.path(hash)
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(p)
+	return err == nil
+}
+
+//Get loads the vector with the given hash and checks that its contents still match the hash
+func (s *Store) Get(hash string) (*Intvector, error) {
+	p, err := s.path(hash)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(p)
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var v Intvector
+	if len(b) > 0 {
+		if err := v.DeserializeFrom(b, false); err != nil {
+			return nil, err
+		}
+	}
+	if v.Hash() != hash {
+		return nil, errors.New("Hash mismatch for " + hash)
+	}
+	return &v, nil
+}
+
+//Delete removes the vector with the given hash
+func (s *Store) Delete(hash string) error {
+	p, err := s.path(hash)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(p)
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	return err
+}
+
+//Hashes returns the hashes of all the vectors in the store
+func (s *Store) Hashes() ([]string, error) {
+	var hashes []string
+	dirs, err := os.ReadDir(filepath.Join(s.dir, "objects"))
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range dirs {
+		if !d.IsDir() {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(s.dir, "objects", d.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if _, err := s.path(f.Name()); err == nil {
+				hashes = append(hashes, f.Name())
+			}
+		}
+	}
+	return hashes, nil
+}
+
+//staleTempAge is how old a temporary file must be before GC assumes the Put that wrote it has crashed
+const staleTempAge = time.Hour
+
+//GC deletes every vector whose hash is not one of the given roots and returns how many were deleted.
+//It also removes the temporary files left behind by a Put that crashed
+func (s *Store) GC(roots []string) (int, error) {
+	if err := s.removeStaleTemps(); err != nil {
+		return 0, err
+	}
+
+	keep := make(map[string]bool)
+	for _, r := range roots {
+		keep[r] = true
+	}
+
+	hashes, err := s.Hashes()
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for _, h := range hashes {
+		if keep[h] {
+			continue
+		}
+		if err := s.Delete(h); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+//removeStaleTemps removes the temporary files of writeFileAtomic that are older than staleTempAge,
+//younger ones may still belong to a running Put
+func (s *Store) removeStaleTemps() error {
+	temps, err := filepath.Glob(filepath.Join(s.dir, "objects", "*", "*"+tmpSuffix+"*"))
+	if err != nil {
+		return err
+	}
+	for _, tmp := range temps {
+		info, err := os.Stat(tmp)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		if time.Since(info.ModTime()) > staleTempAge {
+			if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package intvector
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStore(t *testing.T) {
+	dir := t.TempDir()
+	s, err := OpenStore(dir)
+	if err != nil {
+		t.Fatalf("Store Test failed : OpenStore returned error %s", err)
+	}
+
+	var a, b, empty Intvector
+	a.Insert(1, 2, 3)
+	b.Insert(4, 5, 6)
+
+	ha, err := s.Put(&a)
+	if err != nil || ha != a.Hash() {
+		t.Fatalf("Store Test failed : Put returned %s (err %v), want %s", ha, err, a.Hash())
+	}
+	//the same contents must be deduplicated
+	var a2 Intvector
+	a2.Insert(1, 2, 3)
+	if h, _ := s.Put(&a2); h != ha {
+		t.Errorf("Store Test failed : identical vector got hash %s want %s", h, ha)
+	}
+	hb, _ := s.Put(&b)
+	he, _ := s.Put(&empty)
+
+	if hashes, _ := s.Hashes(); len(hashes) != 3 {
+		t.Errorf("Store Test failed : want 3 stored vectors got %d", len(hashes))
+	}
+
+	if !s.Has(ha) || !s.Has(he) {
+		t.Error("Store Test failed : Has returned false for a stored vector")
+	}
+	got, err := s.Get(hb)
+	if err != nil || got.Hash() != hb {
+		t.Errorf("Store Test failed : Get returned a different vector (err %v)", err)
+	}
+	if got, err := s.Get(he); err != nil || got.Size() != 0 {
+		t.Errorf("Store Test failed : Get of the empty vector returned %v (err %v)", got, err)
+	}
+
+	if err := s.Delete(hb); err != nil {
+		t.Errorf("Store Test failed : Delete returned error %s", err)
+	}
+	if s.Has(hb) {
+		t.Error("Store Test failed : Has returned true after Delete")
+	}
+	if _, err := s.Get(hb); err != ErrNotFound {
+		t.Errorf("Store Test failed : want ErrNotFound after Delete got %v", err)
+	}
+	if err := s.Delete(hb); err != ErrNotFound {
+		t.Errorf("Store Test failed : want ErrNotFound for second Delete got %v", err)
+	}
+
+	//hashes must not be able to point outside the store
+	for _, bad := range []string{"../../etc/passwd", "ab", ha[:63] + "G", "AB" + ha[2:]} {
+		if s.Has(bad) {
+			t.Errorf("Store Test failed : Has returned true for invalid hash %s", bad)
+		}
+		if _, err := s.Get(bad); err == nil {
+			t.Errorf("Store Test failed : Get should return error for invalid hash %s", bad)
+		}
+	}
+
+	//a modified file must be detected
+	p := filepath.Join(dir, "objects", ha[:2], ha)
+	os.WriteFile(p, b.Serialized(), 0644)
+	if _, err := s.Get(ha); err == nil {
+		t.Error("Store Test failed : Get should return error for modified file")
+	}
+}
+
+func TestStoreGC(t *testing.T) {
+	s, _ := OpenStore(t.TempDir())
+
+	var hashes []string
+	for i := 0; i < 5; i++ {
+		var v Intvector
+		v.Push(i)
+		h, _ := s.Put(&v)
+		hashes = append(hashes, h)
+	}
+
+	removed, err := s.GC([]string{hashes[1], hashes[3]})
+	if err != nil || removed != 3 {
+		t.Errorf("Store GC Test failed : want 3 removed got %d (err %v)", removed, err)
+	}
+	for i, h := range hashes {
+		if want := i == 1 || i == 3; s.Has(h) != want {
+			t.Errorf("Store GC Test failed : Has(%d) want %t", i, want)
+		}
+	}
+}
+
+func TestStoreConcurrentPut(t *testing.T) {
+	dir := t.TempDir()
+	s, _ := OpenStore(dir)
+	var v Intvector
+	v.Insert(1, 2, 3)
+
+	//the same vector is put from many goroutines at once, every Put must succeed
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := s.Put(&v)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Store Concurrent Put Test failed : Put returned error %s", err)
+		}
+	}
+
+	//GC removes temporary files left by a crashed Put once they are stale, and ignores stray files
+	p, _ := s.path(v.Hash())
+	stale, fresh := p+tmpSuffix+"1", p+tmpSuffix+"2"
+	os.WriteFile(stale, nil, 0644)
+	os.WriteFile(fresh, nil, 0644)
+	old := time.Now().Add(-2 * staleTempAge)
+	os.Chtimes(stale, old, old)
+	os.WriteFile(filepath.Join(dir, "objects", "README"), []byte("stray"), 0644)
+
+	if hashes, err := s.Hashes(); err != nil || len(hashes) != 1 || hashes[0] != v.Hash() {
+		t.Errorf("Store Concurrent Put Test failed : want only the vector got %v, %v", hashes, err)
+	}
+	if removed, err := s.GC([]string{v.Hash()}); err != nil || removed != 0 {
+		t.Errorf("Store Concurrent Put Test failed : want nothing removed got %d, %v", removed, err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("Store Concurrent Put Test failed : stale temporary file was not removed")
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Error("Store Concurrent Put Test failed : temporary file of a running Put was removed")
+	}
+}
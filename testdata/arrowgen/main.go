@@ -0,0 +1,119 @@
+//go:build arrowgen
+
+//Command arrowgen writes the Arrow IPC fixtures of the intvector tests with the Go reference implementation
+//of Arrow and checks that the reference reader accepts the files written by WriteArrow and WriteArrowFile.
+//It depends on github.com/apache/arrow/go/arrow v0.0.0-20211112161151-bc219186db40, which intvector does not require,
+//so it is only built with the arrowgen tag. It was built in a separate module requiring that version and run
+//with go run -tags arrowgen in the testdata directory, after writing intvector.arrows and intvector.arrow as in TestArrowGolden
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/ipc"
+	"github.com/apache/arrow/go/arrow/memory"
+)
+
+//schema has a non-nullable Int64 column, a nullable Int32 column with nulls, a string and an unsigned column
+var schema = arrow.NewSchema([]arrow.Field{
+	{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+	{Name: "score", Type: arrow.PrimitiveTypes.Int32, Nullable: true},
+	{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+	{Name: "small", Type: arrow.PrimitiveTypes.Uint8},
+}, nil)
+
+//records returns the two record batches of the fixtures
+func records() []array.Record {
+	mem := memory.NewGoAllocator()
+	b := array.NewRecordBuilder(mem, schema)
+	defer b.Release()
+
+	b.Field(0).(*array.Int64Builder).AppendValues([]int64{0, 1, 2, 3, 4}, nil)
+	b.Field(1).(*array.Int32Builder).AppendValues([]int32{-7, 0, 13, 0, 2147483647}, []bool{true, false, true, false, true})
+	b.Field(2).(*array.StringBuilder).AppendValues([]string{"a", "", "ccc", "dd", "e"}, []bool{true, false, true, true, true})
+	b.Field(3).(*array.Uint8Builder).AppendValues([]uint8{255, 1, 2, 3, 4}, nil)
+	first := b.NewRecord()
+
+	b.Field(0).(*array.Int64Builder).AppendValues([]int64{5, 6, 7}, nil)
+	b.Field(1).(*array.Int32Builder).AppendValues([]int32{-2147483648, 0, 5}, []bool{true, false, true})
+	b.Field(2).(*array.StringBuilder).AppendValues([]string{"f", "g", "h"}, nil)
+	b.Field(3).(*array.Uint8Builder).AppendValues([]uint8{5, 6, 7}, nil)
+	second := b.NewRecord()
+
+	return []array.Record{first, second}
+}
+
+func main() {
+	stream, err := os.Create("reference.arrows")
+	check(err)
+	w := ipc.NewWriter(stream, ipc.WithSchema(schema))
+	for _, rec := range records() {
+		check(w.Write(rec))
+	}
+	check(w.Close())
+	check(stream.Close())
+
+	file, err := os.Create("reference.arrow")
+	check(err)
+	fw, err := ipc.NewFileWriter(file, ipc.WithSchema(schema))
+	check(err)
+	for _, rec := range records() {
+		check(fw.Write(rec))
+	}
+	check(fw.Close())
+	check(file.Close())
+
+	//the files written by the package must be accepted by the reference reader
+	readStream("intvector.arrows")
+	readFile("intvector.arrow")
+	readStream("reference.arrows")
+	readFile("reference.arrow")
+}
+
+func readStream(path string) {
+	f, err := os.Open(path)
+	check(err)
+	defer f.Close()
+	r, err := ipc.NewReader(f)
+	check(err)
+	defer r.Release()
+	fmt.Println(path, r.Schema())
+	for r.Next() {
+		printRecord(r.Record())
+	}
+	if r.Err() != nil && r.Err() != io.EOF {
+		check(r.Err())
+	}
+}
+
+func readFile(path string) {
+	f, err := os.Open(path)
+	check(err)
+	defer f.Close()
+	r, err := ipc.NewFileReader(f)
+	check(err)
+	defer r.Close()
+	fmt.Println(path, r.Schema())
+	for i := 0; i < r.NumRecords(); i++ {
+		rec, err := r.Record(i)
+		check(err)
+		printRecord(rec)
+	}
+}
+
+func printRecord(rec array.Record) {
+	for i, col := range rec.Columns() {
+		fmt.Printf("  %s: %v\n", rec.ColumnName(i), col)
+	}
+}
+
+func check(err error) {
+	if err != nil {
+		log.Fatal(err)
+	}
+}
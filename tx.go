@@ -0,0 +1,228 @@
+package intvector
+
+//IntvectorTx is the view of a vector inside a transaction started by Tx.
+//Its mutating methods work on a private copy that only replaces the contents of the vector when the transaction commits
+type IntvectorTx struct {
+	v       *Intvector
+	scratch Intvector
+	started bool
+	dirty   bool
+	//sets and events are collected from the scratch copy and passed to the OnSet hooks and the observers
+	//of the vector on commit, they are only collected if the vector has any
+	sets   []Set
+	events []Event
+}
+
+//Tx runs f as a transaction, either all of its changes are applied to the vector or none of them are.
+//If f returns an error or panics the vector is left exactly as it was, a panic is passed on after that.
+//The changes are applied in a single step, so they are a single entry in the history and the OnSet hooks
+//are only called once the transaction has committed, as are the observers
+func (v *Intvector) Tx(f func(tx *IntvectorTx) error) error {
+	tx := &IntvectorTx{v: v}
+	if len(v.setHooks) > 0 {
+		tx.scratch.OnSet(func(idx int, value int) {
+			tx.sets = append(tx.sets, Set{Idx: idx, New: value})
+		})
+	}
+	if len(v.observers) > 0 {
+		//not nil, so that an empty transaction is not described by the events of the replace
+		tx.events = []Event{}
+		tx.scratch.Subscribe(func(e Event) {
+			tx.events = append(tx.events, e)
+		})
+	}
+	//the vector itself is not touched before f has returned, so a panic needs no cleanup
+	if err := f(tx); err != nil {
+		return err
+	}
+	if !tx.dirty {
+		return nil
+	}
+
+	//the observers get the events of the single calls instead of those of the replace
+	observers := v.observers
+	v.observers = nil
+	v.replace(tx.scratch.vec)
+	v.observers = observers
+
+	for _, e := range tx.sets {
+		for _, h := range v.setHooks {
+			h(e.Idx, e.New)
+		}
+	}
+	if len(v.observers) > 0 {
+		v.dispatch(edit{kind: editReplace, vals: v.vec}, tx.events)
+	}
+	return nil
+}
+
+//begin copies the vector into the scratch space on the first mutating call
+//The copy gets some spare capacity so that a batch of pushes usually needs no further allocation
+func (tx *IntvectorTx) begin() {
+	if tx.started {
+		return
+	}
+	tx.started = true
+	n := len(tx.v.vec)
+	tx.scratch.vec = append(make([]int, 0, n+n/4+32), tx.v.vec...)
+}
+
+//view returns the contents the transaction currently sees
+func (tx *IntvectorTx) view() []int {
+	if tx.started {
+		return tx.scratch.vec
+	}
+	return tx.v.vec
+}
+
+//Push inserts/pushes a new integer at the back of the vector
+func (tx *IntvectorTx) Push(s int) {
+	tx.begin()
+	tx.scratch.Push(s)
+	tx.dirty = true
+}
+
+//Insert appends the given integers to the back of the vector
+func (tx *IntvectorTx) Insert(s ...int) {
+	tx.begin()
+	tx.scratch.Insert(s...)
+	tx.dirty = tx.dirty || len(s) > 0
+}
+
+//Pop removes the last element from the vector and returns it
+func (tx *IntvectorTx) Pop() (int, error) {
+	tx.begin()
+	s, err := tx.scratch.Pop()
+	tx.dirty = tx.dirty || err == nil
+	return s, err
+}
+
+//Shift removes the first element from the vector and returns it
+func (tx *IntvectorTx) Shift() (int, error) {
+	tx.begin()
+	s, err := tx.scratch.Shift()
+	tx.dirty = tx.dirty || err == nil
+	return s, err
+}
+
+//Unshift inserts a new integer in the front of the vector
+func (tx *IntvectorTx) Unshift(s int) {
+	tx.begin()
+	tx.scratch.Unshift(s)
+	tx.dirty = true
+}
+
+//RemoveAt removes the element at the given idx
+func (tx *IntvectorTx) RemoveAt(idx int) error {
+	tx.begin()
+	err := tx.scratch.RemoveAt(idx)
+	tx.dirty = tx.dirty || err == nil
+	return err
+}
+
+//RemoveFirstOf removes the first occurance of the num and returns true - if no num is found, false is returned
+func (tx *IntvectorTx) RemoveFirstOf(num int) bool {
+	tx.begin()
+	found := tx.scratch.RemoveFirstOf(num)
+	tx.dirty = tx.dirty || found
+	return found
+}
+
+//RemoveAll removes all instances of the given number and returns the total count of the number removed
+func (tx *IntvectorTx) RemoveAll(num int) int {
+	tx.begin()
+	count := tx.scratch.RemoveAll(num)
+	tx.dirty = tx.dirty || count > 0
+	return count
+}
+
+//MakeUnique ensures the vector has only unique elements by removing redundent ones
+func (tx *IntvectorTx) MakeUnique() {
+	tx.begin()
+	tx.scratch.MakeUnique()
+	tx.dirty = true
+}
+
+//Clear clears out the vector
+func (tx *IntvectorTx) Clear() {
+	tx.begin()
+	tx.scratch.Clear()
+	tx.dirty = true
+}
+
+//Reverse function can be used to reverse the vector
+func (tx *IntvectorTx) Reverse() {
+	tx.begin()
+	tx.scratch.Reverse()
+	tx.dirty = true
+}
+
+//Swap function swaps two elements of the vector
+func (tx *IntvectorTx) Swap(idx1 int, idx2 int) error {
+	tx.begin()
+	err := tx.scratch.Swap(idx1, idx2)
+	tx.dirty = tx.dirty || err == nil
+	return err
+}
+
+//Set function can be used to set the value at a specific index in the vector
+func (tx *IntvectorTx) Set(idx int, value int) error {
+	tx.begin()
+	err := tx.scratch.Set(idx, value)
+	tx.dirty = tx.dirty || err == nil
+	return err
+}
+
+//SortedPush pushes the incoming element into the vector in a sorted way
+//it is assumed that the Vector is already sorted
+func (tx *IntvectorTx) SortedPush(n int) {
+	tx.begin()
+	tx.scratch.SortedPush(n)
+	tx.dirty = true
+}
+
+//UniquePush pushes the incoming element in the vector if it is not already present.
+//It returns true if the element was inserted, false otherwise
+func (tx *IntvectorTx) UniquePush(n int) bool {
+	tx.begin()
+	pushed := tx.scratch.UniquePush(n)
+	tx.dirty = tx.dirty || pushed
+	return pushed
+}
+
+//Sort function sorts the vector
+func (tx *IntvectorTx) Sort() {
+	tx.begin()
+	tx.scratch.Sort()
+	tx.dirty = true
+}
+
+//ScaleBy scales the entire vector by the given scalefactor
+func (tx *IntvectorTx) ScaleBy(s int) {
+	tx.begin()
+	tx.scratch.ScaleBy(s)
+	tx.dirty = true
+}
+
+//Size returns the size the vector has inside the transaction
+func (tx *IntvectorTx) Size() int {
+	return len(tx.view())
+}
+
+//At returns the element at i as the transaction sees it
+func (tx *IntvectorTx) At(i int) (int, error) {
+	view := Intvector{vec: tx.view()}
+	return view.At(i)
+}
+
+//Search returns the index of the first occurance of n as the transaction sees it, or -1
+func (tx *IntvectorTx) Search(n int) int {
+	view := Intvector{vec: tx.view()}
+	return view.Search(n)
+}
+
+//IsSorted returns true if the vector is sorted as the transaction sees it
+func (tx *IntvectorTx) IsSorted() bool {
+	view := Intvector{vec: tx.view()}
+	return view.IsSorted()
+}
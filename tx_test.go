@@ -0,0 +1,153 @@
+package intvector
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTxCommit(t *testing.T) {
+	var v Intvector
+	v.Insert(5, 1, 3, 1, 4)
+	v.EnableHistory(10)
+
+	err := v.Tx(func(tx *IntvectorTx) error {
+		if n := tx.RemoveAll(1); n != 2 {
+			t.Errorf("TxCommit Test failed : want 2 removed got %d", n)
+		}
+		tx.Sort()
+		for _, n := range []int{2, 6, 0} {
+			tx.SortedPush(n)
+		}
+		return tx.Set(0, -1)
+	})
+	if err != nil {
+		t.Fatalf("TxCommit Test failed : %v", err)
+	}
+
+	want := []int{-1, 2, 3, 4, 5, 6}
+	if v.Size() != len(want) {
+		t.Fatalf("TxCommit Test failed : want size %d got %d", len(want), v.Size())
+	}
+	for i, w := range want {
+		if got, _ := v.At(i); got != w {
+			t.Errorf("TxCommit Test failed : want %d at index %d got %d", w, i, got)
+		}
+	}
+
+	//the whole transaction is a single entry in the history
+	v.Undo()
+	if v.Size() != 5 || v.CanUndo() {
+		t.Errorf("TxCommit Test failed : want a single undo back to 5 elements got %d", v.Size())
+	}
+}
+
+func TestTxRollback(t *testing.T) {
+	var v Intvector
+	v.Insert(1, 2, 3)
+	hash := v.Hash()
+
+	errStop := errors.New("stop")
+	err := v.Tx(func(tx *IntvectorTx) error {
+		tx.Push(4)
+		tx.Set(0, 10)
+		tx.Reverse()
+		if tx.Size() != 4 {
+			t.Errorf("TxRollback Test failed : want the transaction to see 4 elements got %d", tx.Size())
+		}
+		return errStop
+	})
+	if err != errStop {
+		t.Errorf("TxRollback Test failed : want the error of the callback got %v", err)
+	}
+	if v.Hash() != hash {
+		t.Error("TxRollback Test failed : the vector changed after a failed transaction")
+	}
+}
+
+func TestTxPanic(t *testing.T) {
+	var v Intvector
+	v.Insert(1, 2, 3)
+	hash := v.Hash()
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("TxPanic Test failed : want the panic to be passed on got %v", r)
+			}
+		}()
+		v.Tx(func(tx *IntvectorTx) error {
+			tx.Clear()
+			tx.Push(9)
+			panic("boom")
+		})
+	}()
+
+	if v.Hash() != hash {
+		t.Error("TxPanic Test failed : the vector changed after a panicking transaction")
+	}
+}
+
+func TestTxHooks(t *testing.T) {
+	var v Intvector
+	v.Insert(1, 2, 3)
+	calls := 0
+	v.OnSet(func(idx int, value int) {
+		calls++
+		//the hooks only run once the vector holds the new contents
+		if got, _ := v.At(idx); got != value {
+			t.Errorf("TxHooks Test failed : want %d at index %d got %d", value, idx, got)
+		}
+	})
+
+	v.Tx(func(tx *IntvectorTx) error {
+		tx.Set(0, 7)
+		tx.Set(2, 8)
+		if calls != 0 {
+			t.Error("TxHooks Test failed : hooks called before the commit")
+		}
+		return nil
+	})
+	if calls != 2 {
+		t.Errorf("TxHooks Test failed : want 2 calls got %d", calls)
+	}
+
+	v.Tx(func(tx *IntvectorTx) error {
+		tx.Set(1, 9)
+		return errors.New("rollback")
+	})
+	if calls != 2 {
+		t.Errorf("TxHooks Test failed : want no calls for a rolled back transaction got %d", calls-2)
+	}
+}
+
+func TestTxAllocations(t *testing.T) {
+	var v Intvector
+	for i := 0; i < 1000; i++ {
+		v.Push(i)
+	}
+
+	//one allocation for the scratch copy, the other for the transaction itself
+	allocs := testing.AllocsPerRun(50, func() {
+		v.Tx(func(tx *IntvectorTx) error {
+			for i := 0; i < 100; i++ {
+				tx.Push(i)
+			}
+			tx.Set(0, 1)
+			tx.Pop()
+			return nil
+		})
+	})
+	if allocs > 2 {
+		t.Errorf("TxAllocations Test failed : want at most 2 allocations got %f", allocs)
+	}
+
+	//a transaction that changes nothing leaves the vector and its history alone
+	v.EnableHistory(5)
+	v.Tx(func(tx *IntvectorTx) error {
+		tx.RemoveAll(-1)
+		return nil
+	})
+	if v.CanUndo() {
+		t.Error("TxAllocations Test failed : want no history entry for an empty transaction")
+	}
+}
@@ -0,0 +1,248 @@
+package intvector
+
+import (
+	"errors"
+	"math/bits"
+	"sort"
+)
+
+//WaveletTree answers rank, range count and range quantile queries on a static vector in O(log σ),
+//where σ is the number of distinct elements. It is built once in O(n log σ) and does not follow later changes to the vector
+//Internally it is laid out as a wavelet matrix over the ranks of the elements in the sorted alphabet
+type WaveletTree struct {
+	n        int
+	alphabet []int //sorted distinct elements, an element is stored as its index in here
+	//rank0[l][i] is the number of zero bits in the first i positions of level l
+	rank0 [][]int
+	//zeros[l] is the total number of zero bits on level l
+	zeros []int
+}
+
+//NewWaveletTree builds a wavelet tree over the current elements of the given vector
+func NewWaveletTree(v *Intvector) *WaveletTree {
+	alphabet := append([]int{}, v.vec...)
+	sort.Ints(alphabet)
+	u := 0
+	for i, val := range alphabet {
+		if i == 0 || val != alphabet[u-1] {
+			alphabet[u] = val
+			u++
+		}
+	}
+	alphabet = alphabet[:u]
+
+	codes := make([]int, len(v.vec))
+	for i, val := range v.vec {
+		codes[i] = sort.SearchInts(alphabet, val)
+	}
+
+	t := &WaveletTree{n: len(v.vec), alphabet: alphabet}
+	t.build(codes)
+	return t
+}
+
+//levels returns the number of bits needed to store a code
+func (t *WaveletTree) levels() int {
+	if len(t.alphabet) == 0 {
+		return 0
+	}
+	return bits.Len(uint(len(t.alphabet) - 1))
+}
+
+//bit returns bit l of code, counting from the most significant of the used bits
+func (t *WaveletTree) bit(code int, l int) int {
+	return (code >> (t.levels() - 1 - l)) & 1
+}
+
+//build fills in the levels from the codes, every level stably moves the zero bits to the front for the next one
+func (t *WaveletTree) build(codes []int) {
+	levels := t.levels()
+	t.rank0 = make([][]int, levels)
+	t.zeros = make([]int, levels)
+	next := make([]int, len(codes))
+
+	for l := 0; l < levels; l++ {
+		rank := make([]int, len(codes)+1)
+		for i, c := range codes {
+			rank[i+1] = rank[i] + 1 - t.bit(c, l)
+		}
+		t.rank0[l] = rank
+		t.zeros[l] = rank[len(codes)]
+
+		z, o := 0, t.zeros[l]
+		for _, c := range codes {
+			if t.bit(c, l) == 0 {
+				next[z] = c
+				z++
+			} else {
+				next[o] = c
+				o++
+			}
+		}
+		codes, next = next, codes
+	}
+}
+
+//down maps the position i on level l to its position on level l+1 for the given bit
+func (t *WaveletTree) down(l int, i int, bit int) int {
+	if bit == 0 {
+		return t.rank0[l][i]
+	}
+	return t.zeros[l] + i - t.rank0[l][i]
+}
+
+//Size returns the number of elements in the tree
+func (t *WaveletTree) Size() int {
+	return t.n
+}
+
+//Rank returns the number of occurances of value in the first i elements
+func (t *WaveletTree) Rank(value int, i int) (int, error) {
+	if err := checkRange(0, i, t.n); err != nil {
+		return 0, err
+	}
+
+	code := sort.SearchInts(t.alphabet, value)
+	if code == len(t.alphabet) || t.alphabet[code] != value {
+		return 0, nil
+	}
+
+	s, e := 0, i
+	for l := range t.rank0 {
+		b := t.bit(code, l)
+		s, e = t.down(l, s, b), t.down(l, e, b)
+	}
+	return e - s, nil
+}
+
+//RangeQuantile returns the k-th smallest element (counting from 0) in the range [i, j)
+func (t *WaveletTree) RangeQuantile(i int, j int, k int) (int, error) {
+	if err := checkRange(i, j, t.n); err != nil {
+		return 0, err
+	}
+	if k < 0 || k >= j-i {
+		return 0, errors.New("k out of range")
+	}
+
+	code := 0
+	for l := range t.rank0 {
+		z := t.rank0[l][j] - t.rank0[l][i]
+		b := 0
+		if k >= z {
+			k -= z
+			b = 1
+			code |= 1 << (t.levels() - 1 - l)
+		}
+		i, j = t.down(l, i, b), t.down(l, j, b)
+	}
+	if code >= len(t.alphabet) {
+		//only possible for a tree loaded from corrupted bytes
+		return 0, errors.New("Corrupted tree")
+	}
+	return t.alphabet[code], nil
+}
+
+//RangeCount returns the number of elements in the range [i, j) whose value lies in [lo, hi)
+func (t *WaveletTree) RangeCount(i int, j int, lo int, hi int) (int, error) {
+	if err := checkRange(i, j, t.n); err != nil {
+		return 0, err
+	}
+	if lo >= hi {
+		return 0, nil
+	}
+	return t.countLess(i, j, sort.SearchInts(t.alphabet, hi)) - t.countLess(i, j, sort.SearchInts(t.alphabet, lo)), nil
+}
+
+//countLess returns the number of elements in the range [i, j) whose code is less than the given code
+func (t *WaveletTree) countLess(i int, j int, code int) int {
+	if code >= len(t.alphabet) {
+		return j - i
+	}
+
+	count := 0
+	for l := range t.rank0 {
+		b := t.bit(code, l)
+		if b == 1 {
+			count += t.rank0[l][j] - t.rank0[l][i]
+		}
+		i, j = t.down(l, i, b), t.down(l, j, b)
+	}
+	return count
+}
+
+//Serialized returns the tree as a slice of bytes so it can be stored and loaded without being rebuilt
+//The layout is the element count, the alphabet size, the alphabet and then the bits of every level packed 64 to a word,
+//all as 8 byte big endian words. The rank tables are recomputed from the bits in O(n log σ) when loading
+func (t *WaveletTree) Serialized() []byte {
+	b := appendWord(nil, t.n)
+	b = appendWord(b, len(t.alphabet))
+	b = appendWords(b, t.alphabet)
+
+	words := (t.n + 63) / 64
+	for l := range t.rank0 {
+		packed := make([]uint64, words)
+		for i := 0; i < t.n; i++ {
+			if t.rank0[l][i+1] == t.rank0[l][i] {
+				packed[i/64] |= 1 << (i % 64)
+			}
+		}
+		for _, w := range packed {
+			b = appendUint64(b, w)
+		}
+	}
+	return b
+}
+
+//DeserializeFrom replaces the tree with the one encoded in b by Serialized
+func (t *WaveletTree) DeserializeFrom(b []byte) error {
+	r := wordReader{b: b}
+	n, err := r.word()
+	if err != nil {
+		return err
+	}
+	sigma, err := r.word()
+	if err != nil {
+		return err
+	}
+	if n < 0 || sigma < 0 || sigma > n {
+		return errors.New("Invalid header")
+	}
+
+	tmp := WaveletTree{n: n}
+	if tmp.alphabet, err = r.words(sigma); err != nil {
+		return err
+	}
+	if !sort.IntsAreSorted(tmp.alphabet) {
+		return errors.New("Invalid alphabet")
+	}
+
+	//every level packs n bits into words, rounded up without adding to n so that a huge n cannot overflow
+	words := n / 64
+	if n%64 != 0 {
+		words++
+	}
+	levels := tmp.levels()
+	tmp.rank0 = make([][]int, levels)
+	tmp.zeros = make([]int, levels)
+	for l := 0; l < levels; l++ {
+		if len(r.b)/8 < words {
+			return errors.New("Invalid length")
+		}
+		rank := make([]int, n+1)
+		var w uint64
+		for i := 0; i < n; i++ {
+			if i%64 == 0 {
+				w, _ = r.uint64()
+			}
+			rank[i+1] = rank[i] + 1 - int(w>>(i%64)&1)
+		}
+		tmp.rank0[l] = rank
+		tmp.zeros[l] = rank[n]
+	}
+	if !r.done() {
+		return errors.New("Invalid length")
+	}
+
+	*t = tmp
+	return nil
+}
@@ -0,0 +1,102 @@
+package intvector
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+//checkWaveletTree compares every query of the tree against a brute force answer over s
+func checkWaveletTree(t *testing.T, tree *WaveletTree, s []int) {
+	for i := 0; i <= len(s); i++ {
+		for _, value := range []int{-3, 0, 2, 5, 11} {
+			want := 0
+			for _, v := range s[:i] {
+				if v == value {
+					want++
+				}
+			}
+			got, err := tree.Rank(value, i)
+			if err != nil || want != got {
+				t.Errorf("WaveletTree Rank Test failed : want %d got %d (err %v) for value %d in [0, %d)", want, got, err, value, i)
+			}
+		}
+
+		for j := i; j <= len(s); j++ {
+			sorted := append([]int{}, s[i:j]...)
+			sort.Ints(sorted)
+			for k, want := range sorted {
+				got, err := tree.RangeQuantile(i, j, k)
+				if err != nil || want != got {
+					t.Errorf("WaveletTree RangeQuantile Test failed : want %d got %d (err %v) for k %d in [%d, %d)", want, got, err, k, i, j)
+				}
+			}
+
+			lo, hi := -2, 6
+			want := 0
+			for _, v := range s[i:j] {
+				if v >= lo && v < hi {
+					want++
+				}
+			}
+			got, err := tree.RangeCount(i, j, lo, hi)
+			if err != nil || want != got {
+				t.Errorf("WaveletTree RangeCount Test failed : want %d got %d (err %v) for values [%d, %d) in [%d, %d)", want, got, err, lo, hi, i, j)
+			}
+		}
+	}
+}
+
+func TestWaveletTree(t *testing.T) {
+	var s Intvector
+	for i := 0; i < 40; i++ {
+		s.Push(rand.Intn(16) - 4)
+	}
+	tree := NewWaveletTree(&s)
+	checkWaveletTree(t, tree, s.vec)
+
+	if _, err := tree.RangeQuantile(2, 5, 3); err == nil {
+		t.Error("WaveletTree Test failed : RangeQuantile should return error for k out of range")
+	}
+	if _, err := tree.Rank(1, s.Size()+1); err == nil {
+		t.Error("WaveletTree Test failed : Rank should return error for out of range index")
+	}
+	if got, _ := tree.RangeCount(0, s.Size(), 5, 5); got != 0 {
+		t.Errorf("WaveletTree Test failed : want 0 for empty value range, got %d", got)
+	}
+
+	//a single distinct value needs no levels at all
+	single := NewWaveletTree(&Intvector{vec: []int{5, 5, 5}})
+	checkWaveletTree(t, single, []int{5, 5, 5})
+}
+
+func TestWaveletTreeSerialized(t *testing.T) {
+	for _, size := range []int{0, 1, 31, 32, 33, 70} {
+		var s Intvector
+		for i := 0; i < size; i++ {
+			s.Push(rand.Intn(20) - 5)
+		}
+
+		var loaded WaveletTree
+		if err := loaded.DeserializeFrom(NewWaveletTree(&s).Serialized()); err != nil {
+			t.Errorf("WaveletTree Serialized Test failed : DeserializeFrom returned error %s for size %d", err, size)
+			continue
+		}
+		checkWaveletTree(t, &loaded, s.vec)
+	}
+
+	var loaded WaveletTree
+	b := NewWaveletTree(&Intvector{vec: []int{3, 1, 2}}).Serialized()
+	if err := loaded.DeserializeFrom(b[:len(b)-1]); err == nil {
+		t.Error("WaveletTree Serialized Test failed : should return error for truncated input")
+	}
+
+	//a corrupted element count fails instead of overflowing the bitmap size
+	for _, n := range []int{math.MaxInt, math.MaxInt - 63, 1 << 20} {
+		b := appendWords(appendWord(appendWord(nil, n), 2), []int{0, 1})
+		if err := loaded.DeserializeFrom(appendUint64(b, 0)); err == nil {
+			t.Errorf("WaveletTree Serialized Test failed : should return error for element count %d", n)
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package intvector
+
+import (
+	"encoding/binary"
+	"errors"
+)
+
+//The wire representation of a vector is the same on every platform: every element is written as an 8 byte
+//big endian two's complement word, i.e. as an int64, no matter whether int is 32 or 64 bits wide.
+//Serialized, Hash and every structure that is serialized in this package use this representation,
+//so the bytes and the hashes of a vector do not depend on the machine that produced them.
+
+//ErrOverflow is returned when a word does not fit into an int, which can only happen on 32 bit platforms
+var ErrOverflow = errors.New("Value overflows int")
+
+//appendWord appends n to b as an 8 byte big endian word
+func appendWord(b []byte, n int) []byte {
+	return appendUint64(b, uint64(int64(n)))
+}
+
+//appendWords appends every element of s to b as an 8 byte big endian word
+func appendWords(b []byte, s []int) []byte {
+	for _, n := range s {
+		b = appendWord(b, n)
+	}
+	return b
+}
+
+//appendUint64 appends u to b as an 8 byte big endian word
+func appendUint64(b []byte, u uint64) []byte {
+	var w [8]byte
+	binary.BigEndian.PutUint64(w[:], u)
+	return append(b, w[:]...)
+}
+
+//decodeWord converts the 8 byte big endian word at the start of b into an int
+//It returns ErrOverflow if the value does not fit, instead of silently truncating it
+func decodeWord(b []byte) (int, error) {
+	x := int64(binary.BigEndian.Uint64(b))
+	n := int(x)
+	if int64(n) != x {
+		return 0, ErrOverflow
+	}
+	return n, nil
+}
+
+//decodeWords appends the 8 byte words in b to s
+func decodeWords(s []int, b []byte) ([]int, error) {
+	for i := 0; i+8 <= len(b); i += 8 {
+		n, err := decodeWord(b[i:])
+		if err != nil {
+			return nil, err
+		}
+		s = append(s, n)
+	}
+	return s, nil
+}
+
+//wordReader reads 8 byte big endian words from a byte slice
+type wordReader struct {
+	b []byte
+}
+
+//uint64 reads the next word as an unsigned number
+func (r *wordReader) uint64() (uint64, error) {
+	if len(r.b) < 8 {
+		return 0, errors.New("Invalid length")
+	}
+	u := binary.BigEndian.Uint64(r.b)
+	r.b = r.b[8:]
+	return u, nil
+}
+
+//word reads the next word as an int
+func (r *wordReader) word() (int, error) {
+	if len(r.b) < 8 {
+		return 0, errors.New("Invalid length")
+	}
+	n, err := decodeWord(r.b)
+	if err != nil {
+		return 0, err
+	}
+	r.b = r.b[8:]
+	return n, nil
+}
+
+//words reads the next count words as ints
+func (r *wordReader) words(count int) ([]int, error) {
+	if count < 0 || len(r.b)/8 < count {
+		return nil, errors.New("Invalid length")
+	}
+	s := make([]int, count)
+	for i := range s {
+		n, err := r.word()
+		if err != nil {
+			return nil, err
+		}
+		s[i] = n
+	}
+	return s, nil
+}
+
+//done reports whether all the bytes have been read
+func (r *wordReader) done() bool {
+	return len(r.b) == 0
+}
@@ -0,0 +1,74 @@
+package intvector
+
+import (
+	"encoding/hex"
+	"strconv"
+	"testing"
+)
+
+//wireGolden holds the canonical serialization and sha256 hash of a few vectors.
+//The values were generated independently with python's struct.pack(">q") and hashlib.sha256 and must never change,
+//they guarantee that Serialized and Hash give the same result on every platform and in every version
+var wireGolden = []struct {
+	name string
+	wire string
+	hash string
+	//needs64 marks the vectors that can only be represented with 64 bit ints
+	needs64 bool
+}{
+	{"empty", "", "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", false},
+	{"small", "0000000000000000000000000000000100000000000000020000000000000003", "c4c96cd71102046c61ec8326b2566d9e48ef2ba26d4252ba84db28ba352a0079", false},
+	{"negative", "fffffffffffffffffffffffffffffffefffffffffffffffdffffffffffffff80ffffffffffffff7f", "380003139036736b53e7ec1f3d1df584eaadf17f200afb795e0e9d3d9f922a3a", false},
+	{"int32 bounds", "ffffffff80000000000000007fffffff0000000000000000", "e3f10e78c8620ab55af01b30f24f8a2dd2c44f97d59fe1ee340af1fa8a897fc5", false},
+	{"int64 bounds", "80000000000000007fffffffffffffff", "5f51e546e3138a3e90bf1deeaaedd739ef6329a6718e5b517a2e586f86305831", true},
+}
+
+func TestWireGolden(t *testing.T) {
+	for _, g := range wireGolden {
+		wire, _ := hex.DecodeString(g.wire)
+
+		var s Intvector
+		err := s.DeserializeFrom(wire, false)
+		if len(wire) == 0 {
+			//an empty byte array is rejected by DeserializeFrom, the empty vector is checked directly
+			err = nil
+		}
+
+		if g.needs64 && strconv.IntSize == 32 {
+			if err != ErrOverflow {
+				t.Errorf("Wire Golden Test failed for %s : want ErrOverflow on 32 bit platforms, got %v", g.name, err)
+			}
+			if s.Size() != 0 {
+				t.Errorf("Wire Golden Test failed for %s : vector was modified by a failed DeserializeFrom", g.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Wire Golden Test failed for %s : DeserializeFrom returned error %s", g.name, err)
+			continue
+		}
+
+		if got := hex.EncodeToString(s.Serialized()); got != g.wire {
+			t.Errorf("Wire Golden Test failed for %s : want serialization %s got %s", g.name, g.wire, got)
+		}
+		if got := s.Hash(); got != g.hash {
+			t.Errorf("Wire Golden Test failed for %s : want hash %s got %s", g.name, g.hash, got)
+		}
+	}
+}
+
+func TestDecodeWord(t *testing.T) {
+	got, err := decodeWord([]byte{255, 255, 255, 255, 255, 255, 255, 253})
+	if err != nil || got != -3 {
+		t.Errorf("DecodeWord Test failed : want -3 got %d (err %v)", got, err)
+	}
+
+	//2^32 only fits into a 64 bit int
+	_, err = decodeWord([]byte{0, 0, 0, 1, 0, 0, 0, 0})
+	if strconv.IntSize == 32 && err != ErrOverflow {
+		t.Errorf("DecodeWord Test failed : want ErrOverflow on 32 bit platforms, got %v", err)
+	}
+	if strconv.IntSize == 64 && err != nil {
+		t.Errorf("DecodeWord Test failed : want no error on 64 bit platforms, got %v", err)
+	}
+}